@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+var xmlEncodingPattern = regexp.MustCompile(`^<\?xml[^>]*\bencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// normalizeCharset uses the WHATWG labels, the same table browsers use.
+func normalizeCharset(label string) string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if _, name := charset.Lookup(label); name != "" {
+		return name
+	}
+	return label
+}
+
+// detectCharset prefers a BOM, then the Content-Type, then the XML declaration.
+func detectCharset(body []byte, contentType string) string {
+	switch {
+	case bytes.HasPrefix(body, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8"
+	case bytes.HasPrefix(body, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	case bytes.HasPrefix(body, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	}
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		return normalizeCharset(params["charset"])
+	}
+	if match := xmlEncodingPattern.FindSubmatch(bytes.TrimSpace(body)); match != nil {
+		return normalizeCharset(string(match[1]))
+	}
+	return "utf-8"
+}
+
+// toUTF8 lets a BOM win over the charset name, since servers mislabel UTF-16 more often.
+func toUTF8(body []byte, label string) ([]byte, error) {
+	encoding, _ := charset.Lookup(label)
+	if encoding == nil {
+		return nil, fmt.Errorf("Unsupported character encoding: %q", label)
+	}
+	decoded, _, err := transform.Bytes(unicode.BOMOverride(encoding.NewDecoder()), body)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding %v text: %w", label, err)
+	}
+	return decoded, nil
+}
+
+func newUTF8Decoder(body []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var pubDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+	"Mon, 2 Jan 2006 15:04:05",
+	"Mon, 2 January 2006 15:04:05 -0700",
+	"Mon, 2 January 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04 MST",
+	"2 Jan 2006 15:04:05",
+	"2 January 2006 15:04:05 -0700",
+	"2 January 2006 15:04:05 MST",
+	"2 Jan 2006",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC850,
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+}
+
+// zoneOffsets holds the US zones RFC 822 allows, which time.Parse would treat as UTC.
+var zoneOffsets = map[string]int{
+	"EST": -5 * 60 * 60,
+	"EDT": -4 * 60 * 60,
+	"CST": -6 * 60 * 60,
+	"CDT": -5 * 60 * 60,
+	"MST": -7 * 60 * 60,
+	"MDT": -6 * 60 * 60,
+	"PST": -8 * 60 * 60,
+	"PDT": -7 * 60 * 60,
+}
+
+func normalizePubDate(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if i := strings.Index(value, " ("); i > 0 && strings.HasSuffix(value, ")") {
+		value = value[:i]
+	}
+	if strings.HasSuffix(value, " UT") || strings.HasSuffix(value, " Z") {
+		value = value[:strings.LastIndex(value, " ")] + " UTC"
+	}
+	return strings.Replace(value, " Sept ", " Sep ", 1)
+}
+
+func fixZone(parsed time.Time) time.Time {
+	name, offset := parsed.Zone()
+	if known, ok := zoneOffsets[name]; ok && offset == 0 {
+		return time.Date(parsed.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), parsed.Nanosecond(), time.FixedZone(name, known))
+	}
+	return parsed
+}
+
+func parsePubDate(value string) (time.Time, error) {
+	value = normalizePubDate(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("Missing publish date")
+	}
+	candidates := []string{value}
+	if i := strings.Index(value, ", "); i > 0 && i <= len("Wednesday") {
+		candidates = append(candidates, value[i+2:])
+	}
+	for _, candidate := range candidates {
+		for _, layout := range pubDateLayouts {
+			if parsed, err := time.Parse(layout, candidate); err == nil {
+				return fixZone(parsed), nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("Unrecognized publish date format: %q", value)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type discoveredFeed struct {
+	URL   string
+	Title string
+	Type  string
+	feed  *RSSFeed
+}
+
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+	"application/json":      true,
+}
+
+var feedFormatTypes = map[string]string{
+	"RSS":       "application/rss+xml",
+	"Atom":      "application/atom+xml",
+	"JSON Feed": "application/feed+json",
+}
+
+var commonFeedPaths = []string{"feed", "rss.xml", "atom.xml", "feed.xml", "index.xml", "rss", "feed.json"}
+
+func isHTMLDocument(body []byte, contentType string) bool {
+	if strings.Contains(contentType, "text/html") || strings.Contains(contentType, "application/xhtml+xml") {
+		return true
+	}
+	start := bytes.ToLower(bytes.TrimSpace(body))
+	if len(start) > 512 {
+		start = start[:512]
+	}
+	return bytes.HasPrefix(start, []byte("<!doctype html")) || bytes.Contains(start, []byte("<html"))
+}
+
+func discoverFeedLinks(pageURL string, body []byte) []discoveredFeed {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	var found []discoveredFeed
+	seen := map[string]bool{}
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return found
+		}
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+		token := tokenizer.Token()
+		attrs := map[string]string{}
+		for _, attr := range token.Attr {
+			attrs[attr.Key] = strings.TrimSpace(attr.Val)
+		}
+		switch token.DataAtom {
+		case atom.Base:
+			if ref, err := url.Parse(attrs["href"]); err == nil && attrs["href"] != "" {
+				base = base.ResolveReference(ref)
+			}
+			continue
+		case atom.Body:
+			return found
+		case atom.Link:
+		default:
+			continue
+		}
+		alternate := false
+		for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+			if rel == "alternate" {
+				alternate = true
+			}
+		}
+		linkType := strings.ToLower(attrs["type"])
+		if !alternate || !feedLinkTypes[linkType] || attrs["href"] == "" {
+			continue
+		}
+		ref, err := url.Parse(attrs["href"])
+		if err != nil {
+			continue
+		}
+		resolved := base.ResolveReference(ref).String()
+		if seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
+		found = append(found, discoveredFeed{
+			URL:   resolved,
+			Title: stripControls(attrs["title"]),
+			Type:  linkType,
+		})
+	}
+}
+
+func fetchPage(ctx context.Context, pageURL string, limits fetchLimits) ([]byte, string, error) {
+	req, err := newFetchRequest(ctx, pageURL)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := limits.hosts.wait(ctx, pageURL); err != nil {
+		return nil, "", err
+	}
+	client := &http.Client{Timeout: limits.timeout}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, "", timeoutError(ctx, pageURL, limits, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, "", statusError(res, pageURL, limits)
+	}
+	body, err := readLimitedBody(res, pageURL, limits)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, res.Header.Get("Content-Type"), nil
+}
+
+// feedProbeURLs puts the paths next to the page ahead of those at the root.
+func feedProbeURLs(pageURL string) [][]string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	var groups [][]string
+	seen := map[string]bool{}
+	for _, prefix := range []string{"", "/"} {
+		var probes []string
+		for _, candidate := range commonFeedPaths {
+			probe := base.ResolveReference(&url.URL{Path: prefix + candidate}).String()
+			if !seen[probe] && probe != pageURL {
+				seen[probe] = true
+				probes = append(probes, probe)
+			}
+		}
+		if len(probes) > 0 {
+			groups = append(groups, probes)
+		}
+	}
+	return groups
+}
+
+// probeCommonFeedPaths stops at the first feed in each group and skips repeated titles.
+func probeCommonFeedPaths(ctx context.Context, pageURL string, limits fetchLimits) []discoveredFeed {
+	var found []discoveredFeed
+	titles := map[string]bool{}
+	for _, probes := range feedProbeURLs(pageURL) {
+		for _, probe := range probes {
+			feed, err := fetchNewFeed(ctx, probe, limits)
+			if err != nil {
+				continue
+			}
+			title := strings.TrimSpace(feed.Channel.Title)
+			if title == "" || !titles[title] {
+				titles[title] = true
+				found = append(found, discoveredFeed{
+					URL:   probe,
+					Title: title,
+					Type:  feedFormatTypes[feed.Format],
+					feed:  feed,
+				})
+			}
+			break
+		}
+	}
+	return found
+}
+
+func chooseFeed(feeds []discoveredFeed, in io.Reader) (discoveredFeed, error) {
+	if len(feeds) == 1 {
+		return feeds[0], nil
+	}
+	fmt.Println("Found several feeds on that page:")
+	for i, feed := range feeds {
+		title := feed.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		fmt.Printf("	%d) %v | %v | %v\n", i+1, title, feed.URL, feed.Type)
+	}
+	fmt.Printf("Pick a feed [1-%d]: ", len(feeds))
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		return discoveredFeed{}, fmt.Errorf("Error reading choice: %w", err)
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(feeds) {
+		return discoveredFeed{}, fmt.Errorf("Error: Choice must be a number between 1 and %d.", len(feeds))
+	}
+	return feeds[choice-1], nil
+}
+
+// resolveFeedURL offers the feeds a web page links to, or that sit at common paths.
+func resolveFeedURL(ctx context.Context, feedURL string, limits fetchLimits) (string, *RSSFeed, error) {
+	feed, err := fetchNewFeed(ctx, feedURL, limits)
+	if err == nil {
+		return feedURL, feed, nil
+	}
+	var page *notFeedError
+	if !errors.As(err, &page) {
+		return "", nil, err
+	}
+	if !isHTMLDocument(page.Body, page.ContentType) {
+		return "", nil, fmt.Errorf("%s is neither a feed nor a web page: %v", feedURL, page.Err)
+	}
+	feeds := discoverFeedLinks(page.URL, page.Body)
+	if len(feeds) == 0 {
+		feeds = probeCommonFeedPaths(ctx, page.URL, limits)
+	}
+	if len(feeds) == 0 {
+		return "", nil, fmt.Errorf("%s is a web page and no feed could be found on it", feedURL)
+	}
+	chosen, err := chooseFeed(feeds, os.Stdin)
+	if err != nil {
+		return "", nil, err
+	}
+	fmt.Printf("Using feed %v\n", chosen.URL)
+	if chosen.feed != nil {
+		return chosen.URL, chosen.feed, nil
+	}
+	feed, err = fetchNewFeed(ctx, chosen.URL, limits)
+	if err != nil {
+		return "", nil, err
+	}
+	return chosen.URL, feed, nil
+}
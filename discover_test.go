@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiscoverFeedLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want []discoveredFeed
+	}{
+		{
+			name: "RSS, Atom and JSON links",
+			page: `<html><head>
+<link rel="alternate" type="application/rss+xml" title="Posts" href="/feed.xml">
+<link rel="Alternate" type="Application/Atom+XML" href="atom.xml">
+<link rel="alternate" type="application/feed+json" title="JSON" href="https://cdn.example.com/feed.json">
+</head></html>`,
+			want: []discoveredFeed{
+				{URL: "https://example.com/feed.xml", Title: "Posts", Type: "application/rss+xml"},
+				{URL: "https://example.com/blog/atom.xml", Type: "application/atom+xml"},
+				{URL: "https://cdn.example.com/feed.json", Title: "JSON", Type: "application/feed+json"},
+			},
+		},
+		{
+			name: "base href",
+			page: `<head><base href="https://other.example.com/root/"><link rel="alternate" type="application/rss+xml" href="rss"></head>`,
+			want: []discoveredFeed{
+				{URL: "https://other.example.com/root/rss", Type: "application/rss+xml"},
+			},
+		},
+		{
+			name: "other links and duplicates are ignored",
+			page: `<head>
+<link rel="stylesheet" type="text/css" href="/style.css">
+<link rel="alternate" type="text/html" hreflang="fr" href="/fr/">
+<link rel="alternate" type="application/rss+xml" href="">
+<link rel="alternate" type="application/rss+xml" href="/feed">
+<link rel="alternate" type="application/rss+xml" href="https://example.com/feed">
+</head>`,
+			want: []discoveredFeed{
+				{URL: "https://example.com/feed", Type: "application/rss+xml"},
+			},
+		},
+		{
+			name: "links in the body are ignored",
+			page: `<html><body><link rel="alternate" type="application/rss+xml" href="/feed"></body></html>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := discoverFeedLinks("https://example.com/blog/", []byte(tt.page))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("discoverFeedLinks = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedProbeURLs(t *testing.T) {
+	groups := feedProbeURLs("https://example.com/blog/")
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	if groups[0][0] != "https://example.com/blog/feed" || groups[1][0] != "https://example.com/feed" {
+		t.Errorf("first probes = %q, %q", groups[0][0], groups[1][0])
+	}
+	if len(groups[0]) != len(commonFeedPaths) || len(groups[1]) != len(commonFeedPaths) {
+		t.Errorf("group sizes = %d, %d, want %d each", len(groups[0]), len(groups[1]), len(commonFeedPaths))
+	}
+	root := feedProbeURLs("https://example.com/")
+	if len(root) != 1 || len(root[0]) != len(commonFeedPaths) {
+		t.Errorf("probes for a root page = %q, want one group of %d", root, len(commonFeedPaths))
+	}
+	if feedProbeURLs("://bad") != nil {
+		t.Errorf("probes for an invalid URL should be nil")
+	}
+}
+
+func TestProbeCommonFeedPaths(t *testing.T) {
+	rss := func(title string) string {
+		return fmt.Sprintf(`<?xml version="1.0"?><rss version="2.0"><channel><title>%s</title></channel></rss>`, title)
+	}
+	tests := []struct {
+		name      string
+		feeds     map[string]string
+		wantURLs  []string
+		wantTypes []string
+	}{
+		{
+			name:      "first hit per prefix",
+			feeds:     map[string]string{"/blog/feed": rss("Blog"), "/blog/rss.xml": rss("Blog copy"), "/rss.xml": rss("Site")},
+			wantURLs:  []string{"/blog/feed", "/rss.xml"},
+			wantTypes: []string{"application/rss+xml", "application/rss+xml"},
+		},
+		{
+			name:      "same title at the root",
+			feeds:     map[string]string{"/blog/atom.xml": `<feed xmlns="http://www.w3.org/2005/Atom"><title>Blog</title></feed>`, "/feed": rss("Blog")},
+			wantURLs:  []string{"/blog/atom.xml"},
+			wantTypes: []string{"application/atom+xml"},
+		},
+		{
+			name:  "nothing found",
+			feeds: map[string]string{"/blog/feed": "<html>not a feed</html>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, ok := tt.feeds[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+				fmt.Fprint(w, body)
+			}))
+			defer server.Close()
+			found := probeCommonFeedPaths(context.Background(), server.URL+"/blog/", fetchLimits{timeout: 5 * time.Second, maxBytes: 1 << 20})
+			var urls, types []string
+			for _, feed := range found {
+				urls = append(urls, feed.URL[len(server.URL):])
+				types = append(types, feed.Type)
+			}
+			if !reflect.DeepEqual(urls, tt.wantURLs) || !reflect.DeepEqual(types, tt.wantTypes) {
+				t.Errorf("found %q %q, want %q %q", urls, types, tt.wantURLs, tt.wantTypes)
+			}
+		})
+	}
+}
+
+func TestResolveFeedURL(t *testing.T) {
+	pages := map[string]string{
+		"/site.rss":  `<?xml version="1.0"?><rss version="2.0"><channel><title>Site</title><item><title>One</title></item></channel></rss>`,
+		"/blog/":     `<html><head><link rel="alternate" type="application/rss+xml" href="/site.rss"></head><body></body></html>`,
+		"/empty/":    `<html><head></head><body>No feeds</body></html>`,
+		"/data.json": `{"hello": "world"}`,
+	}
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+	limits := fetchLimits{timeout: 5 * time.Second, maxBytes: 1 << 20}
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "feed", path: "/site.rss", want: "/site.rss"},
+		{name: "page with a feed link", path: "/blog/", want: "/site.rss"},
+		{name: "page without feeds", path: "/empty/", wantErr: true},
+		{name: "neither a feed nor a page", path: "/data.json", wantErr: true},
+		{name: "missing page", path: "/missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clear(requests)
+			got, feed, err := resolveFeedURL(context.Background(), server.URL+tt.path, limits)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("resolveFeedURL = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveFeedURL: %v", err)
+			}
+			if got != server.URL+tt.want || feed == nil || feed.Channel.Title != "Site" || len(feed.Channel.Item) != 1 {
+				t.Errorf("resolveFeedURL = %q, %+v", got, feed)
+			}
+			if requests[tt.want] != 1 {
+				t.Errorf("feed was downloaded %d times, want once", requests[tt.want])
+			}
+		})
+	}
+}
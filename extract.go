@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Rota-of-light/blogAgg/internal/database"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+var errNoArticle = errors.New("No article content found on the page")
+
+func parseHTMLDocument(s string) (*html.Node, error) {
+	root, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return nil, err
+	}
+	removeDropped(root)
+	return root, nil
+}
+
+func removeDropped(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.CommentNode || (child.Type == html.ElementNode && droppedTags[child.Data]) {
+			n.RemoveChild(child)
+		} else {
+			removeDropped(child)
+		}
+		child = next
+	}
+}
+
+func textContent(n *html.Node, out *strings.Builder) {
+	if n.Type == html.TextNode {
+		out.WriteString(n.Data)
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		textContent(child, out)
+	}
+}
+
+func innerText(n *html.Node) string {
+	var out strings.Builder
+	textContent(n, &out)
+	return strings.Join(strings.Fields(out.String()), " ")
+}
+
+// linkDensity is the share of a node's text that sits inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(innerText(n))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	walkHTML(n, func(node *html.Node) bool {
+		if node.Type == html.ElementNode && node.Data == "a" {
+			linked += len(innerText(node))
+			return false
+		}
+		return true
+	})
+	return float64(linked) / float64(total)
+}
+
+func walkHTML(n *html.Node, visit func(*html.Node) bool) {
+	if !visit(n) {
+		return
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		walkHTML(child, visit)
+	}
+}
+
+func resolveLinks(n *html.Node, base *url.URL) {
+	walkHTML(n, func(node *html.Node) bool {
+		for i, attr := range node.Attr {
+			if attr.Namespace == "" && (attr.Key == "href" || attr.Key == "src") {
+				node.Attr[i].Val = resolveURL(base, attr.Val)
+			}
+		}
+		return true
+	})
+}
+
+var (
+	unlikelyCandidate = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|footer|header|menu|modal|newsletter|pager|popup|related|remark|share|sidebar|social|sponsor|subscribe|widget`)
+	maybeCandidate    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveWeight    = regexp.MustCompile(`(?i)article|body|content|entry|hentry|main|page|post|story|text`)
+	negativeWeight    = regexp.MustCompile(`(?i)comment|com-|contact|footer|footnote|masthead|meta|nav|promo|related|scroll|share|shoutbox|sidebar|social|sponsor|tags|widget`)
+)
+
+var unlikelyTags = map[string]bool{
+	"nav":    true,
+	"header": true,
+	"footer": true,
+	"aside":  true,
+	"button": true,
+	"menu":   true,
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, value := range []string{attrValue(n, "class"), attrValue(n, "id")} {
+		if value == "" {
+			continue
+		}
+		if positiveWeight.MatchString(value) {
+			weight += 25
+		}
+		if negativeWeight.MatchString(value) {
+			weight -= 25
+		}
+	}
+	return weight
+}
+
+func tagWeight(tag string) float64 {
+	switch tag {
+	case "article":
+		return 10
+	case "div", "main", "section":
+		return 5
+	case "pre", "td", "blockquote":
+		return 3
+	case "ol", "ul", "dl", "dd", "dt", "li", "form":
+		return -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		return -5
+	}
+	return 0
+}
+
+// removeUnlikely drops navigation, sidebars and comments before scoring.
+func removeUnlikely(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.ElementNode && child.Data != "body" && child.Data != "article" {
+			match := attrValue(child, "class") + " " + attrValue(child, "id")
+			if unlikelyTags[child.Data] || (unlikelyCandidate.MatchString(match) && !maybeCandidate.MatchString(match)) {
+				n.RemoveChild(child)
+				child = next
+				continue
+			}
+		}
+		removeUnlikely(child)
+		child = next
+	}
+}
+
+// extractArticle scores containers the way Readability does and keeps the best one with its
+// closest siblings.
+func extractArticle(page string, pageURL string) (string, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	root, err := parseHTMLDocument(page)
+	if err != nil {
+		return "", err
+	}
+	removeUnlikely(root)
+	resolveLinks(root, base)
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(node *html.Node, score float64) {
+		if node == nil || node.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[node]; !ok {
+			scores[node] = tagWeight(node.Data) + classWeight(node)
+			candidates = append(candidates, node)
+		}
+		scores[node] += score
+	}
+	walkHTML(root, func(node *html.Node) bool {
+		if node.Type != html.ElementNode || (node.Data != "p" && node.Data != "pre" && node.Data != "td") {
+			return true
+		}
+		text := innerText(node)
+		if len(text) < 25 {
+			return false
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text)/100), 3)
+		addScore(node.Parent, score)
+		if node.Parent != nil {
+			addScore(node.Parent.Parent, score/2)
+		}
+		return false
+	})
+	var best *html.Node
+	for _, node := range candidates {
+		scores[node] *= 1 - linkDensity(node)
+		if best == nil || scores[node] > scores[best] {
+			best = node
+		}
+	}
+	if best == nil {
+		return "", errNoArticle
+	}
+	threshold := max(10, scores[best]*0.2)
+	var out strings.Builder
+	siblings := []*html.Node{best}
+	if best.Parent != nil {
+		siblings = nil
+		for sibling := best.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+			siblings = append(siblings, sibling)
+		}
+	}
+	for _, sibling := range siblings {
+		keep := sibling == best
+		if score, ok := scores[sibling]; ok && score >= threshold {
+			keep = true
+		}
+		if sibling.Type == html.ElementNode && sibling.Data == "p" {
+			text := innerText(sibling)
+			density := linkDensity(sibling)
+			if (len(text) > 80 && density < 0.25) || (len(text) > 0 && density == 0 && strings.Contains(text, ". ")) {
+				keep = true
+			}
+		}
+		if keep {
+			if err := html.Render(&out, sibling); err != nil {
+				return "", err
+			}
+		}
+	}
+	content := sanitizeHTML(out.String())
+	if len(htmlToText(content)) < 200 {
+		return "", errNoArticle
+	}
+	return content, nil
+}
+
+func fetchArticle(ctx context.Context, s *state, post database.Post) (string, error) {
+	limits := newFetchLimits(s)
+	limits.maxBytes = s.config.ArticleSizeLimit()
+	body, contentType, err := fetchPage(ctx, post.Url, limits)
+	if err != nil {
+		return "", err
+	}
+	if !isHTMLDocument(body, contentType) {
+		return "", fmt.Errorf("Article is not an HTML page: %v", contentType)
+	}
+	_, encoding, _ := charset.DetermineEncoding(body, contentType)
+	page, err := toUTF8(body, encoding)
+	if err != nil {
+		return "", err
+	}
+	return extractArticle(string(page), post.Url)
+}
+
+// maxArticleAttempts bounds how often a page that keeps timing out or failing with 5xx is tried.
+const maxArticleAttempts = 3
+
+// extractPending leases posts first, so several agg instances do not fetch the same page.
+func extractPending(ctx context.Context, s *state, limit int32) (int, error) {
+	workers := s.config.ExtractWorkerCount()
+	rounds := (int(limit) + workers - 1) / workers
+	lease := time.Duration(rounds)*(s.config.FetchTimeout()+s.hosts.spacing()) + time.Minute
+	now := time.Now()
+	posts, err := s.db.ClaimPostsNeedingContent(ctx, database.ClaimPostsNeedingContentParams{
+		LeaseExpiresAt: sql.NullTime{
+			Time:  now.Add(lease),
+			Valid: true,
+		},
+		MaxAttempts: maxArticleAttempts,
+		Now: sql.NullTime{
+			Time:  now,
+			Valid: true,
+		},
+		LimitCount: limit,
+	})
+	if err != nil {
+		return 0, err
+	}
+	jobs := make(chan database.Post)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	extracted := 0
+	var dbErr error
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for post := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				content, err := fetchArticle(ctx, s, post)
+				if err != nil && ctx.Err() != nil {
+					continue
+				}
+				if temporaryFetchError(err) {
+					log.Printf("Article from %v will be retried: %v", post.Url, err)
+					continue
+				}
+				if err != nil {
+					log.Printf("Failed to extract article from %v: %v", post.Url, err)
+				}
+				saveErr := s.db.SaveExtractedContent(context.WithoutCancel(ctx), database.SaveExtractedContentParams{
+					Content: optionalString(content),
+					ContentFetchedAt: sql.NullTime{
+						Time:  time.Now(),
+						Valid: true,
+					},
+					ID: post.ID,
+				})
+				mu.Lock()
+				if saveErr != nil && dbErr == nil {
+					dbErr = saveErr
+				}
+				if saveErr == nil && err == nil {
+					extracted++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, post := range posts {
+		jobs <- post
+	}
+	close(jobs)
+	wg.Wait()
+	return extracted, dbErr
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExtractArticle(t *testing.T) {
+	paragraph := "This paragraph is part of the article, with enough words, commas, and detail to score well against the page furniture around it."
+	page := `<!doctype html><html><head><title>Post</title><script>var tracking = 1;</script></head><body>
+<nav class="menu"><a href="/">Home</a> <a href="/about">About</a> <a href="/archive">Archive of every post we have ever written</a></nav>
+<div id="sidebar"><p>Sidebar text that talks about other posts, newsletters, and a great many other things.</p></div>
+<div class="content">
+<article>
+<h1>The title</h1>
+<p>` + paragraph + `</p>
+<p>` + paragraph + ` It links to <a href="../related">a related post</a>.</p>
+<script>alert("x")</script>
+<p>` + paragraph + `</p>
+<img src="images/chart.png" alt="Chart">
+</article>
+</div>
+<div class="comments"><p>First comment, saying that this post was great, and thanking the author for it.</p></div>
+<footer><p>Copyright notice, address, phone number, and all the rest of the small print.</p></footer>
+</body></html>`
+	got, err := extractArticle(page, "https://example.com/blog/posts/one")
+	if err != nil {
+		t.Fatalf("extractArticle: %v", err)
+	}
+	for _, want := range []string{"The title", paragraph, `href="https://example.com/blog/related"`, `src="https://example.com/blog/posts/images/chart.png"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("article is missing %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"Archive of every post", "Sidebar text", "First comment", "Copyright notice", "alert", "tracking"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("article contains %q:\n%s", unwanted, got)
+		}
+	}
+}
+
+func TestExtractArticleRejectsShortPages(t *testing.T) {
+	pages := []string{
+		`<html><body><p>Too short.</p></body></html>`,
+		`<html><body><nav><a href="/a">A link that is long enough to be scored, but only a link</a></nav></body></html>`,
+		``,
+	}
+	for _, page := range pages {
+		if _, err := extractArticle(page, "https://example.com/"); !errors.Is(err, errNoArticle) {
+			t.Errorf("extractArticle(%q) error = %v, want errNoArticle", page, err)
+		}
+	}
+}
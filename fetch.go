@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Rota-of-light/blogAgg/internal/config"
+)
+
+// fetchLimits.timeout covers a whole request, and maxBytes the decompressed body.
+type fetchLimits struct {
+	timeout  time.Duration
+	maxBytes int64
+	hosts    *hostLimiter
+}
+
+func newFetchLimits(s *state) fetchLimits {
+	return fetchLimits{
+		timeout:  s.config.FetchTimeout(),
+		maxBytes: s.config.FeedSizeLimit(),
+		hosts:    s.hosts,
+	}
+}
+
+// hostLimiter is a token bucket per host with a minimum gap between requests.
+type hostLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	minDelay time.Duration
+	hosts    map[string]*hostSchedule
+}
+
+// hostSchedule stores the bucket as the time it empties again (GCRA).
+type hostSchedule struct {
+	emptyAt     time.Time
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newHostLimiter(cfg *config.Config) *hostLimiter {
+	return &hostLimiter{
+		interval: time.Duration(float64(time.Minute) / cfg.HostRate()),
+		burst:    cfg.HostBurstSize(),
+		minDelay: cfg.HostDelay(),
+		hosts:    make(map[string]*hostSchedule),
+	}
+}
+
+func (l *hostLimiter) reserve(host string, now time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	schedule, ok := l.hosts[host]
+	if !ok {
+		schedule = &hostSchedule{emptyAt: now}
+		l.hosts[host] = schedule
+	}
+	start := schedule.emptyAt.Add(-time.Duration(l.burst-1) * l.interval)
+	if start.Before(now) {
+		start = now
+	}
+	if !schedule.last.IsZero() && start.Before(schedule.last.Add(l.minDelay)) {
+		start = schedule.last.Add(l.minDelay)
+	}
+	if schedule.emptyAt.Before(start) {
+		schedule.emptyAt = start
+	}
+	schedule.emptyAt = schedule.emptyAt.Add(l.interval)
+	schedule.last = start
+	return start
+}
+
+func (l *hostLimiter) spacing() time.Duration {
+	if l == nil {
+		return 0
+	}
+	return max(l.interval, l.minDelay)
+}
+
+func hostOf(target string) (string, error) {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(parsed.Hostname()), nil
+}
+
+func (l *hostLimiter) pause(target string, until time.Time) {
+	host, err := hostOf(target)
+	if l == nil || err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	schedule, ok := l.hosts[host]
+	if !ok {
+		schedule = &hostSchedule{emptyAt: time.Now()}
+		l.hosts[host] = schedule
+	}
+	if until.After(schedule.pausedUntil) {
+		schedule.pausedUntil = until
+	}
+}
+
+func (l *hostLimiter) pausedUntil(host string, now time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if schedule, ok := l.hosts[host]; ok && schedule.pausedUntil.After(now) {
+		return schedule.pausedUntil
+	}
+	return time.Time{}
+}
+
+// HostPausedError is returned instead of waiting out a Retry-After.
+type HostPausedError struct {
+	Host  string
+	Until time.Time
+}
+
+func (e *HostPausedError) Error() string {
+	return fmt.Sprintf("%s asked not to be contacted until %s", e.Host, e.Until.Format(time.RFC1123))
+}
+
+func (l *hostLimiter) wait(ctx context.Context, target string) error {
+	if l == nil {
+		return nil
+	}
+	host, err := hostOf(target)
+	if err != nil {
+		return err
+	}
+	if until := l.pausedUntil(host, time.Now()); !until.IsZero() {
+		return &HostPausedError{Host: host, Until: until}
+	}
+	delay := time.Until(l.reserve(host, time.Now()))
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+const maxRetryAfter = 7 * 24 * time.Hour
+
+func retryAfter(res *http.Response, now time.Time) time.Duration {
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	value := strings.TrimSpace(res.Header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if when, err := http.ParseTime(value); err == nil {
+		delay = when.Sub(now)
+	}
+	if delay <= 0 {
+		return 0
+	}
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	return delay
+}
+
+// statusError pauses the whole host when the response has a Retry-After.
+func statusError(res *http.Response, target string, limits fetchLimits) *HTTPStatusError {
+	err := &HTTPStatusError{
+		URL:        target,
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		RetryAfter: retryAfter(res, time.Now()),
+	}
+	if err.RetryAfter > 0 {
+		limits.hosts.pause(target, time.Now().Add(err.RetryAfter))
+	}
+	return err
+}
+
+// temporaryFetchError reports failures worth retrying later: timeouts, connection errors,
+// paused hosts and 429 or 5xx responses.
+func temporaryFetchError(err error) bool {
+	var timeout *FetchTimeoutError
+	var paused *HostPausedError
+	var netErr net.Error
+	var status *HTTPStatusError
+	switch {
+	case errors.As(err, &timeout), errors.As(err, &paused), errors.As(err, &netErr):
+		return true
+	case errors.As(err, &status):
+		return status.StatusCode == http.StatusTooManyRequests || status.StatusCode >= 500
+	}
+	return false
+}
+
+type ResponseTooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("Response from %s is larger than the %d byte limit", e.URL, e.Limit)
+}
+
+type FetchTimeoutError struct {
+	URL     string
+	Timeout time.Duration
+}
+
+func (e *FetchTimeoutError) Error() string {
+	return fmt.Sprintf("Request to %s timed out after %s", e.URL, e.Timeout)
+}
+
+// newFetchRequest asks for compression itself, which turns off the transport's gzip handling.
+func newFetchRequest(ctx context.Context, target string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	return req, nil
+}
+
+// timeoutError prefers the context's cause, so a deadline or shutdown is not blamed on the server.
+func timeoutError(ctx context.Context, target string, limits fetchLimits, err error) error {
+	if err == nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &FetchTimeoutError{URL: target, Timeout: limits.timeout}
+	}
+	return err
+}
+
+// decompressBody accepts zlib and raw data for deflate, since servers disagree.
+func decompressBody(res *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return res.Body, nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading gzip response: %w", err)
+		}
+		return reader, nil
+	case "deflate":
+		buffered := bufio.NewReader(res.Body)
+		header, err := buffered.Peek(2)
+		if err == nil && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 && header[0]&0x0F == 8 {
+			reader, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading deflate response: %w", err)
+			}
+			return reader, nil
+		}
+		return flate.NewReader(buffered), nil
+	default:
+		return nil, fmt.Errorf("Unsupported content encoding: %q", res.Header.Get("Content-Encoding"))
+	}
+}
+
+// readLimitedBody applies the limit after decompression.
+func readLimitedBody(res *http.Response, target string, limits fetchLimits) ([]byte, error) {
+	if res.Header.Get("Content-Encoding") == "" && res.ContentLength > limits.maxBytes {
+		return nil, &ResponseTooLargeError{URL: target, Limit: limits.maxBytes}
+	}
+	reader, err := decompressBody(res)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	body, err := io.ReadAll(io.LimitReader(reader, limits.maxBytes+1))
+	if err != nil {
+		return nil, timeoutError(res.Request.Context(), target, limits, err)
+	}
+	if int64(len(body)) > limits.maxBytes {
+		return nil, &ResponseTooLargeError{URL: target, Limit: limits.maxBytes}
+	}
+	return body, nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func compressed(t *testing.T, encoding string, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "zlib":
+		writer = zlib.NewWriter(&buf)
+	case "flate":
+		var err error
+		if writer, err = flate.NewWriter(&buf, flate.DefaultCompression); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		return []byte(body)
+	}
+	if _, err := io.WriteString(writer, body); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressBody(t *testing.T) {
+	const body = "<rss><channel><title>Compressed</title></channel></rss>"
+	tests := []struct {
+		name            string
+		contentEncoding string
+		data            []byte
+		wantErr         bool
+	}{
+		{"no encoding", "", compressed(t, "", body), false},
+		{"identity", "identity", compressed(t, "", body), false},
+		{"gzip", "gzip", compressed(t, "gzip", body), false},
+		{"x-gzip", "x-gzip", compressed(t, "gzip", body), false},
+		{"gzip in capitals", " GZIP ", compressed(t, "gzip", body), false},
+		{"deflate as zlib", "deflate", compressed(t, "zlib", body), false},
+		{"deflate as raw data", "deflate", compressed(t, "flate", body), false},
+		{"broken gzip", "gzip", []byte("not gzip at all"), true},
+		{"unsupported", "br", []byte(body), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{
+				Header: http.Header{},
+				Body:   io.NopCloser(bytes.NewReader(tt.data)),
+			}
+			if tt.contentEncoding != "" {
+				res.Header.Set("Content-Encoding", tt.contentEncoding)
+			}
+			reader, err := decompressBody(res)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("decompressBody succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decompressBody: %v", err)
+			}
+			defer reader.Close()
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("reading body: %v", err)
+			}
+			if string(got) != body {
+				t.Errorf("body = %q, want %q", got, body)
+			}
+		})
+	}
+}
+
+func TestReadLimitedBodyCountsDecompressedBytes(t *testing.T) {
+	data := compressed(t, "gzip", strings.Repeat("a", 1000))
+	res := &http.Response{
+		Header:        http.Header{"Content-Encoding": {"gzip"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       &http.Request{},
+	}
+	_, err := readLimitedBody(res, "https://example.com/feed", fetchLimits{maxBytes: 100})
+	var tooLarge *ResponseTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Errorf("readLimitedBody error = %v, want a ResponseTooLargeError", err)
+	}
+}
+
+func TestHostLimiterReserve(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		interval time.Duration
+		burst    int
+		minDelay time.Duration
+		// requests are made at start plus each offset, all to the same host
+		at   []time.Duration
+		want []time.Duration
+	}{
+		{
+			name:     "burst then rate",
+			interval: 2 * time.Second,
+			burst:    3,
+			at:       []time.Duration{0, 0, 0, 0, 0},
+			want:     []time.Duration{0, 0, 0, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:     "minimum delay inside the burst",
+			interval: 2 * time.Second,
+			burst:    3,
+			minDelay: time.Second,
+			at:       []time.Duration{0, 0, 0, 0, 0},
+			want:     []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second},
+		},
+		{
+			name:     "bucket refills while idle",
+			interval: 2 * time.Second,
+			burst:    2,
+			at:       []time.Duration{0, 0, 0, time.Minute, time.Minute},
+			want:     []time.Duration{0, 0, 2 * time.Second, time.Minute, time.Minute},
+		},
+		{
+			name:     "no burst",
+			interval: time.Second,
+			burst:    1,
+			at:       []time.Duration{0, 0, 0},
+			want:     []time.Duration{0, time.Second, 2 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &hostLimiter{
+				interval: tt.interval,
+				burst:    tt.burst,
+				minDelay: tt.minDelay,
+				hosts:    make(map[string]*hostSchedule),
+			}
+			for i, offset := range tt.at {
+				got := limiter.reserve("example.com", start.Add(offset)).Sub(start)
+				if got != tt.want[i] {
+					t.Errorf("request %d starts at +%v, want +%v", i+1, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHostLimiterHostsAreIndependent(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := &hostLimiter{interval: time.Minute, burst: 1, hosts: make(map[string]*hostSchedule)}
+	limiter.reserve("a.example.com", now)
+	if got := limiter.reserve("b.example.com", now); !got.Equal(now) {
+		t.Errorf("second host starts at %v, want %v", got, now)
+	}
+	if got := limiter.reserve("a.example.com", now); !got.Equal(now.Add(time.Minute)) {
+		t.Errorf("first host starts again at %v, want %v", got, now.Add(time.Minute))
+	}
+}
+
+func TestHostLimiterPause(t *testing.T) {
+	limiter := &hostLimiter{interval: time.Millisecond, burst: 1, hosts: make(map[string]*hostSchedule)}
+	limiter.pause("https://Example.com/feed", time.Now().Add(time.Hour))
+	err := limiter.wait(context.Background(), "https://example.com/other")
+	var paused *HostPausedError
+	if !errors.As(err, &paused) || paused.Host != "example.com" {
+		t.Errorf("wait error = %v, want a HostPausedError for example.com", err)
+	}
+	if err := limiter.wait(context.Background(), "https://example.org/feed"); err != nil {
+		t.Errorf("wait on another host = %v, want nil", err)
+	}
+	var nilLimiter *hostLimiter
+	if err := nilLimiter.wait(context.Background(), "https://example.com/"); err != nil {
+		t.Errorf("nil limiter wait = %v, want nil", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		status int
+		header string
+		want   time.Duration
+	}{
+		{"seconds", http.StatusTooManyRequests, "120", 2 * time.Minute},
+		{"http date", http.StatusServiceUnavailable, "Thu, 01 Jan 2026 12:10:00 GMT", 10 * time.Minute},
+		{"date in the past", http.StatusServiceUnavailable, "Thu, 01 Jan 2026 11:00:00 GMT", 0},
+		{"capped", http.StatusTooManyRequests, "99999999", maxRetryAfter},
+		{"ignored on other statuses", http.StatusInternalServerError, "120", 0},
+		{"garbage", http.StatusTooManyRequests, "soon", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{StatusCode: tt.status, Header: http.Header{"Retry-After": {tt.header}}}
+			if got := retryAfter(res, now); got != tt.want {
+				t.Errorf("retryAfter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemporaryFetchError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no error", nil, false},
+		{"timeout", &FetchTimeoutError{URL: "https://example.com/"}, true},
+		{"paused host", &HostPausedError{Host: "example.com"}, true},
+		{"connection refused", &url.Error{Op: "Get", URL: "https://example.com/", Err: errors.New("connection refused")}, true},
+		{"too many requests", &HTTPStatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"server error", fmt.Errorf("fetching: %w", &HTTPStatusError{StatusCode: http.StatusBadGateway}), true},
+		{"not found", &HTTPStatusError{StatusCode: http.StatusNotFound}, false},
+		{"too large", &ResponseTooLargeError{URL: "https://example.com/"}, false},
+		{"no article", errNoArticle, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := temporaryFetchError(tt.err); got != tt.want {
+				t.Errorf("temporaryFetchError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
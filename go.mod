@@ -3,6 +3,13 @@ module github.com/Rota-of-light/blogAgg
 go 1.23.4
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
 )
+
+require (
+	golang.org/x/net v0.38.0
+	golang.org/x/text v0.23.0
+)
+
+require github.com/DATA-DOG/go-sqlmock v1.5.2
@@ -4,20 +4,126 @@ import (
     "encoding/json"
     "os"
     "path/filepath"
+    "time"
 )
 
 type Config struct {
     DBURL          string `json:"db_url"`
     CurrentUserName string `json:"current_user_name"`
+    KeepPostRevisions bool `json:"keep_post_revisions,omitempty"`
+    DownloadDir string `json:"download_dir,omitempty"`
+    MaxDownloadBytes int64 `json:"max_download_bytes,omitempty"`
+    FetchTimeoutSeconds int `json:"fetch_timeout_seconds,omitempty"`
+    MaxFeedBytes int64 `json:"max_feed_bytes,omitempty"`
+    HostRequestsPerMinute float64 `json:"host_requests_per_minute,omitempty"`
+    HostBurst int `json:"host_burst,omitempty"`
+    HostDelayMillis int `json:"host_delay_ms,omitempty"`
+    ExtractWorkers int `json:"extract_workers,omitempty"`
+    MaxArticleBytes int64 `json:"max_article_bytes,omitempty"`
+    ArticlesPerCycle int `json:"articles_per_cycle,omitempty"`
 }
 
 const configFileName = ".gatorconfig.json"
 
+const defaultDownloadDir = "gator-downloads"
+
+const defaultMaxDownloadBytes = 500 * 1024 * 1024
+
+const defaultFetchTimeout = 30 * time.Second
+
+const defaultMaxFeedBytes = 10 * 1024 * 1024
+
+const defaultHostRequestsPerMinute = 30
+
+const defaultHostBurst = 3
+
+const defaultHostDelay = time.Second
+
+const defaultExtractWorkers = 2
+
+const defaultMaxArticleBytes = 2 * 1024 * 1024
+
+const defaultArticlesPerCycle = 20
+
 func (cfg *Config) SetUser(username string) error {
 	cfg.CurrentUserName = username
 	return write(*cfg)
 }
 
+func (cfg *Config) DownloadDirectory() (string, error) {
+	if cfg.DownloadDir != "" {
+		return cfg.DownloadDir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, defaultDownloadDir), nil
+}
+
+func (cfg *Config) DownloadLimit() int64 {
+	if cfg.MaxDownloadBytes > 0 {
+		return cfg.MaxDownloadBytes
+	}
+	return defaultMaxDownloadBytes
+}
+
+func (cfg *Config) FetchTimeout() time.Duration {
+	if cfg.FetchTimeoutSeconds > 0 {
+		return time.Duration(cfg.FetchTimeoutSeconds) * time.Second
+	}
+	return defaultFetchTimeout
+}
+
+func (cfg *Config) FeedSizeLimit() int64 {
+	if cfg.MaxFeedBytes > 0 {
+		return cfg.MaxFeedBytes
+	}
+	return defaultMaxFeedBytes
+}
+
+func (cfg *Config) HostRate() float64 {
+	if cfg.HostRequestsPerMinute > 0 {
+		return cfg.HostRequestsPerMinute
+	}
+	return defaultHostRequestsPerMinute
+}
+
+func (cfg *Config) HostBurstSize() int {
+	if cfg.HostBurst > 0 {
+		return cfg.HostBurst
+	}
+	return defaultHostBurst
+}
+
+func (cfg *Config) HostDelay() time.Duration {
+	if cfg.HostDelayMillis > 0 {
+		return time.Duration(cfg.HostDelayMillis) * time.Millisecond
+	}
+	return defaultHostDelay
+}
+
+func (cfg *Config) ExtractWorkerCount() int {
+	if cfg.ExtractWorkers > 0 {
+		return cfg.ExtractWorkers
+	}
+	return defaultExtractWorkers
+}
+
+func (cfg *Config) ArticleSizeLimit() int64 {
+	if cfg.MaxArticleBytes > 0 {
+		return cfg.MaxArticleBytes
+	}
+	return defaultMaxArticleBytes
+}
+
+func (cfg *Config) ArticleBatchSize() int {
+	if cfg.ArticlesPerCycle > 0 {
+		return cfg.ArticlesPerCycle
+	}
+	return defaultArticlesPerCycle
+}
+
 func Read() (Config, error) {
 	var cfg Config
 	path, err := getConfigFilePath()
@@ -7,10 +7,12 @@ package database
 
 import (
 	"context"
+
+	"github.com/lib/pq"
 )
 
 const getFeedsByURLS = `-- name: GetFeedsByURLS :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, date_parse_failures, last_date_parse_error, etag, last_modified, last_error, last_error_at, consecutive_failures, last_success_at, next_fetch_at, lease_owner, lease_expires_at, fetch_interval_seconds, adaptive_interval, title, description, site_link, language, image_url, fetch_full_content, skip_hours, skip_days, full_content_since FROM feeds
 WHERE url = $1
 `
 
@@ -25,6 +27,28 @@ func (q *Queries) GetFeedsByURLS(ctx context.Context, url string) (Feed, error)
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.DateParseFailures,
+		&i.LastDateParseError,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.LastSuccessAt,
+		&i.NextFetchAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.FetchIntervalSeconds,
+		&i.AdaptiveInterval,
+		&i.Title,
+		&i.Description,
+		&i.SiteLink,
+		&i.Language,
+		&i.ImageUrl,
+		&i.FetchFullContent,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.FullContentSince,
 	)
 	return i, err
 }
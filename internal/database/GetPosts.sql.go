@@ -7,26 +7,30 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const getPostsByUser = `-- name: GetPostsByUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id FROM posts
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.guid, posts.content_hash, posts.content_html, posts.author, posts.categories, posts.comments_url, posts.enclosure_url, posts.enclosure_type, posts.enclosure_length, posts.downloaded_at, posts.download_path, posts.content, posts.content_fetched_at, posts.download_failed_at, posts.download_error, posts.content_lease_expires_at, posts.download_lease_expires_at, posts.content_attempts FROM posts
 INNER JOIN feed_follows
 ON feed_follows.feed_id = posts.feed_id
 WHERE feed_follows.user_id = $1
+AND ($2::TEXT IS NULL OR $2::TEXT = ANY(posts.categories))
 ORDER BY posts.published_at DESC
-LIMIT $2
+LIMIT $3
 `
 
 type GetPostsByUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID     uuid.UUID
+	Category   sql.NullString
+	LimitCount int32
 }
 
 func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsByUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsByUser, arg.UserID, arg.Category, arg.LimitCount)
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +47,24 @@ func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams)
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Guid,
+			&i.ContentHash,
+			&i.ContentHtml,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.CommentsUrl,
+			&i.EnclosureUrl,
+			&i.EnclosureType,
+			&i.EnclosureLength,
+			&i.DownloadedAt,
+			&i.DownloadPath,
+			&i.Content,
+			&i.ContentFetchedAt,
+			&i.DownloadFailedAt,
+			&i.DownloadError,
+			&i.ContentLeaseExpiresAt,
+			&i.DownloadLeaseExpiresAt,
+			&i.ContentAttempts,
 		); err != nil {
 			return nil, err
 		}
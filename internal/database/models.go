@@ -12,13 +12,35 @@ import (
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Name                 string
+	Url                  string
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	DateParseFailures    int32
+	LastDateParseError   sql.NullString
+	Etag                 sql.NullString
+	LastModified         sql.NullString
+	LastError            sql.NullString
+	LastErrorAt          sql.NullTime
+	ConsecutiveFailures  int32
+	LastSuccessAt        sql.NullTime
+	NextFetchAt          sql.NullTime
+	LeaseOwner           sql.NullString
+	LeaseExpiresAt       sql.NullTime
+	FetchIntervalSeconds sql.NullInt32
+	AdaptiveInterval     bool
+	Title                sql.NullString
+	Description          sql.NullString
+	SiteLink             sql.NullString
+	Language             sql.NullString
+	ImageUrl             sql.NullString
+	FetchFullContent     bool
+	SkipHours            []string
+	SkipDays             []string
+	FullContentSince     sql.NullTime
 }
 
 type FeedFollow struct {
@@ -27,17 +49,56 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Folder    sql.NullString
+}
+
+type FeedHistory struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	FeedID    uuid.UUID
+	Event     string
+	OldUrl    string
+	NewUrl    string
 }
 
 type Post struct {
+	ID                     uuid.UUID
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	Title                  sql.NullString
+	Url                    string
+	Description            sql.NullString
+	PublishedAt            sql.NullTime
+	FeedID                 uuid.UUID
+	Guid                   string
+	ContentHash            sql.NullString
+	ContentHtml            sql.NullString
+	Author                 sql.NullString
+	Categories             []string
+	CommentsUrl            sql.NullString
+	EnclosureUrl           sql.NullString
+	EnclosureType          sql.NullString
+	EnclosureLength        sql.NullInt64
+	DownloadedAt           sql.NullTime
+	DownloadPath           sql.NullString
+	Content                sql.NullString
+	ContentFetchedAt       sql.NullTime
+	DownloadFailedAt       sql.NullTime
+	DownloadError          sql.NullString
+	ContentLeaseExpiresAt  sql.NullTime
+	DownloadLeaseExpiresAt sql.NullTime
+	ContentAttempts        int32
+}
+
+type PostRevision struct {
 	ID          uuid.UUID
 	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	PostID      uuid.UUID
 	Title       sql.NullString
 	Url         string
 	Description sql.NullString
 	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	ContentHash sql.NullString
 }
 
 type User struct {
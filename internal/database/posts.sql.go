@@ -11,10 +11,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, content_html, author, categories, comments_url, enclosure_url, enclosure_type, enclosure_length)
 VALUES (
     $1,
     $2,
@@ -23,21 +24,39 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16,
+    $17
 )
-ON CONFLICT (url) DO NOTHING
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+ON CONFLICT (feed_id, guid) DO NOTHING
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, content_html, author, categories, comments_url, enclosure_url, enclosure_type, enclosure_length, downloaded_at, download_path, content, content_fetched_at, download_failed_at, download_error, content_lease_expires_at, download_lease_expires_at, content_attempts
 `
 
 type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       sql.NullString
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           sql.NullString
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	Guid            string
+	ContentHash     sql.NullString
+	ContentHtml     sql.NullString
+	Author          sql.NullString
+	Categories      []string
+	CommentsUrl     sql.NullString
+	EnclosureUrl    sql.NullString
+	EnclosureType   sql.NullString
+	EnclosureLength sql.NullInt64
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -50,6 +69,15 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Guid,
+		arg.ContentHash,
+		arg.ContentHtml,
+		arg.Author,
+		pq.Array(arg.Categories),
+		arg.CommentsUrl,
+		arg.EnclosureUrl,
+		arg.EnclosureType,
+		arg.EnclosureLength,
 	)
 	var i Post
 	err := row.Scan(
@@ -61,6 +89,229 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ContentHtml,
+		&i.Author,
+		pq.Array(&i.Categories),
+		&i.CommentsUrl,
+		&i.EnclosureUrl,
+		&i.EnclosureType,
+		&i.EnclosureLength,
+		&i.DownloadedAt,
+		&i.DownloadPath,
+		&i.Content,
+		&i.ContentFetchedAt,
+		&i.DownloadFailedAt,
+		&i.DownloadError,
+		&i.ContentLeaseExpiresAt,
+		&i.DownloadLeaseExpiresAt,
+		&i.ContentAttempts,
 	)
 	return i, err
 }
+
+const getPostByFeedGUID = `-- name: GetPostByFeedGUID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, content_html, author, categories, comments_url, enclosure_url, enclosure_type, enclosure_length, downloaded_at, download_path, content, content_fetched_at, download_failed_at, download_error, content_lease_expires_at, download_lease_expires_at, content_attempts FROM posts
+WHERE feed_id = $1 AND guid = $2
+`
+
+type GetPostByFeedGUIDParams struct {
+	FeedID uuid.UUID
+	Guid   string
+}
+
+func (q *Queries) GetPostByFeedGUID(ctx context.Context, arg GetPostByFeedGUIDParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByFeedGUID, arg.FeedID, arg.Guid)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ContentHtml,
+		&i.Author,
+		pq.Array(&i.Categories),
+		&i.CommentsUrl,
+		&i.EnclosureUrl,
+		&i.EnclosureType,
+		&i.EnclosureLength,
+		&i.DownloadedAt,
+		&i.DownloadPath,
+		&i.Content,
+		&i.ContentFetchedAt,
+		&i.DownloadFailedAt,
+		&i.DownloadError,
+		&i.ContentLeaseExpiresAt,
+		&i.DownloadLeaseExpiresAt,
+		&i.ContentAttempts,
+	)
+	return i, err
+}
+
+const getPostsByURL = `-- name: GetPostsByURL :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, content_html, author, categories, comments_url, enclosure_url, enclosure_type, enclosure_length, downloaded_at, download_path, content, content_fetched_at, download_failed_at, download_error, content_lease_expires_at, download_lease_expires_at, content_attempts FROM posts
+WHERE url = $1
+`
+
+func (q *Queries) GetPostsByURL(ctx context.Context, url string) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsByURL, url)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Guid,
+			&i.ContentHash,
+			&i.ContentHtml,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.CommentsUrl,
+			&i.EnclosureUrl,
+			&i.EnclosureType,
+			&i.EnclosureLength,
+			&i.DownloadedAt,
+			&i.DownloadPath,
+			&i.Content,
+			&i.ContentFetchedAt,
+			&i.DownloadFailedAt,
+			&i.DownloadError,
+			&i.ContentLeaseExpiresAt,
+			&i.DownloadLeaseExpiresAt,
+			&i.ContentAttempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updatePost = `-- name: UpdatePost :exec
+UPDATE posts
+SET title = $1, url = $2, description = $3, published_at = $4, content_hash = $5, updated_at = $6,
+    content_html = $7, author = $8, categories = $9, comments_url = $10,
+    enclosure_url = $11, enclosure_type = $12, enclosure_length = $13
+WHERE id = $14
+`
+
+type UpdatePostParams struct {
+	Title           sql.NullString
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	ContentHash     sql.NullString
+	UpdatedAt       time.Time
+	ContentHtml     sql.NullString
+	Author          sql.NullString
+	Categories      []string
+	CommentsUrl     sql.NullString
+	EnclosureUrl    sql.NullString
+	EnclosureType   sql.NullString
+	EnclosureLength sql.NullInt64
+	ID              uuid.UUID
+}
+
+func (q *Queries) UpdatePost(ctx context.Context, arg UpdatePostParams) error {
+	_, err := q.db.ExecContext(ctx, updatePost,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.ContentHash,
+		arg.UpdatedAt,
+		arg.ContentHtml,
+		arg.Author,
+		pq.Array(arg.Categories),
+		arg.CommentsUrl,
+		arg.EnclosureUrl,
+		arg.EnclosureType,
+		arg.EnclosureLength,
+		arg.ID,
+	)
+	return err
+}
+
+const getLegacyPost = `-- name: GetLegacyPost :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, content_html, author, categories, comments_url, enclosure_url, enclosure_type, enclosure_length, downloaded_at, download_path, content, content_fetched_at, download_failed_at, download_error, content_lease_expires_at, download_lease_expires_at, content_attempts FROM posts
+WHERE feed_id = $1 AND guid = ANY($2::TEXT[])
+LIMIT 1
+`
+
+type GetLegacyPostParams struct {
+	FeedID uuid.UUID
+	Guids  []string
+}
+
+func (q *Queries) GetLegacyPost(ctx context.Context, arg GetLegacyPostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getLegacyPost, arg.FeedID, pq.Array(arg.Guids))
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ContentHtml,
+		&i.Author,
+		pq.Array(&i.Categories),
+		&i.CommentsUrl,
+		&i.EnclosureUrl,
+		&i.EnclosureType,
+		&i.EnclosureLength,
+		&i.DownloadedAt,
+		&i.DownloadPath,
+		&i.Content,
+		&i.ContentFetchedAt,
+		&i.DownloadFailedAt,
+		&i.DownloadError,
+		&i.ContentLeaseExpiresAt,
+		&i.DownloadLeaseExpiresAt,
+		&i.ContentAttempts,
+	)
+	return i, err
+}
+
+const updatePostGUID = `-- name: UpdatePostGUID :exec
+UPDATE posts
+SET guid = $1
+WHERE id = $2
+`
+
+type UpdatePostGUIDParams struct {
+	Guid string
+	ID   uuid.UUID
+}
+
+func (q *Queries) UpdatePostGUID(ctx context.Context, arg UpdatePostGUIDParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostGUID, arg.Guid, arg.ID)
+	return err
+}
@@ -10,18 +10,31 @@ import (
 	"time"
 	"context"
 	"github.com/google/uuid"
+	"math"
 	"net/http"
 	"encoding/xml"
-	"io"
 	"html"
 	"strconv"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"errors"
+	"flag"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"os/signal"
+	"syscall"
 )
 
 import _ "github.com/lib/pq"
 
 type state struct {
 	db		*database.Queries
+	conn	*sql.DB
 	config  *config.Config
+	hosts   *hostLimiter
 }
 
 type command struct {
@@ -46,10 +59,20 @@ func (c *commands) run(s *state, cmd command) error {
 }
 
 type RSSFeed struct {
+	Format  string `xml:"-"`
+	// PermanentURL is where 301/308 redirects led, if anywhere.
+	PermanentURL string `xml:"-"`
 	Channel struct {
 		Title       string    `xml:"title"`
+		// AtomLinks keeps <atom:link> out of Link.
+		AtomLinks   []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
 		Link        string    `xml:"link"`
 		Description string    `xml:"description"`
+		Language    string    `xml:"language"`
+		ImageURL    string    `xml:"image>url"`
+		TTL         string    `xml:"ttl"`
+		SkipHours   []string  `xml:"skipHours>hour"`
+		SkipDays    []string  `xml:"skipDays>day"`
 		Item        []RSSItem `xml:"item"`
 	} `xml:"channel"`
 }
@@ -59,65 +82,916 @@ type RSSItem struct {
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+	GUID        string `xml:"guid"`
+	ContentEncoded string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Creator        string        `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Author         string        `xml:"author"`
+	Categories     []string      `xml:"category"`
+	Comments       string        `xml:"comments"`
+	Enclosure      *RSSEnclosure `xml:"enclosure"`
+	// RawLink is the link before it was resolved and normalized.
+	RawLink string `xml:"-"`
 }
 
-func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+func itemAuthor(item RSSItem) string {
+	if item.Creator != "" {
+		return strings.TrimSpace(item.Creator)
+	}
+	return strings.TrimSpace(item.Author)
+}
+
+func itemCategories(item RSSItem) []string {
+	return trimmedStrings(item.Categories)
+}
+
+func trimmedStrings(values []string) []string {
+	trimmed := []string{}
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
+
+func optionalString(value string) sql.NullString {
+	return sql.NullString{
+		String: value,
+		Valid:  value != "",
+	}
+}
+
+func itemGUID(item RSSItem) string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+	if link := strings.TrimSpace(item.Link); link != "" {
+		return link
+	}
+	return hashKey(item.Title + "\n" + item.Description)
+}
+
+// itemGUIDs keys guid-less items that share a link by their text.
+func itemGUIDs(items []RSSItem) []string {
+	keys := make([]string, len(items))
+	seen := map[string]int{}
+	for i, item := range items {
+		keys[i] = itemGUID(item)
+		seen[keys[i]]++
+	}
+	for i, item := range items {
+		if strings.TrimSpace(item.GUID) == "" && seen[keys[i]] > 1 {
+			keys[i] = hashKey(item.Title + "\n" + item.Description)
+		}
+	}
+	return keys
+}
+
+func hashKey(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func itemURL(item RSSItem) string {
+	if item.Link == "" && (strings.HasPrefix(item.GUID, "http://") || strings.HasPrefix(item.GUID, "https://")) {
+		return normalizeURL(item.GUID)
+	}
+	return item.Link
+}
+
+type AtomFeed struct {
+	Lang     string      `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Base     string      `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	Logo     string      `xml:"logo"`
+	Icon     string      `xml:"icon"`
+	Link     []AtomLink  `xml:"link"`
+	Entry    []AtomEntry `xml:"entry"`
+}
+
+type AtomLink struct {
+	Base   string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// AtomText keeps the raw markup of type="xhtml" content in Inner.
+type AtomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t AtomText) String() string {
+	switch t.Type {
+	case "html", "text/html":
+		return t.Text
+	case "xhtml", "application/xhtml+xml":
+	default:
+		return html.EscapeString(t.Text)
+	}
+	inner := strings.TrimSpace(t.Inner)
+	open := strings.IndexByte(inner, '>')
+	end := strings.LastIndex(inner, "</")
+	if open == len(inner)-1 && strings.HasSuffix(inner, "/>") {
+		return ""
+	}
+	if open < 0 || end <= open || !strings.HasSuffix(inner, "div>") {
+		return inner
+	}
+	return strings.TrimSpace(inner[open+1 : end])
+}
+
+type AtomEntry struct {
+	Base      string     `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Link      []AtomLink `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   AtomText   `xml:"summary"`
+	Content   AtomText   `xml:"content"`
+	Author    []string       `xml:"author>name"`
+	Category  []AtomCategory `xml:"category"`
+}
+
+func (l AtomLink) resolve(base string) string {
+	if l.Base != "" {
+		base = resolveAgainst(base, l.Base)
+	}
+	return resolveAgainst(base, l.Href)
+}
+
+// atomLinkRels are the registered rels that never point at the entry's page.
+var atomLinkRels = map[string]bool{
+	"self":      true,
+	"enclosure": true,
+	"related":   true,
+	"via":       true,
+	"replies":   true,
+	"edit":      true,
+	"license":   true,
+	"hub":       true,
+	"first":     true,
+	"last":      true,
+	"next":      true,
+	"previous":  true,
+}
+
+func alternateLink(links []AtomLink) (AtomLink, bool) {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link, true
+		}
+	}
+	for _, link := range links {
+		if !atomLinkRels[strings.ToLower(link.Rel)] {
+			return link, true
+		}
+	}
+	return AtomLink{}, false
+}
+
+func (a *AtomFeed) toRSS() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = a.Title
+	if link, ok := alternateLink(a.Link); ok {
+		feed.Channel.Link = link.resolve(a.Base)
+	}
+	feed.Channel.Description = a.Subtitle
+	feed.Channel.Language = a.Lang
+	feed.Channel.ImageURL = resolveAgainst(a.Base, a.Logo)
+	if feed.Channel.ImageURL == "" {
+		feed.Channel.ImageURL = resolveAgainst(a.Base, a.Icon)
+	}
+	for _, entry := range a.Entry {
+		base := resolveAgainst(a.Base, entry.Base)
+		if base == "" {
+			base = a.Base
+		}
+		item := RSSItem{
+			Title:       entry.Title,
+			Description: entry.Summary.String(),
+			PubDate:     entry.Published,
+			GUID:        entry.ID,
+			ContentEncoded: entry.Content.String(),
+			Author:         strings.Join(entry.Author, ", "),
+		}
+		if link, ok := alternateLink(entry.Link); ok {
+			item.Link = link.resolve(base)
+			item.RawLink = strings.TrimSpace(link.Href)
+		}
+		if item.Description == "" {
+			item.Description = item.ContentEncoded
+		}
+		for _, category := range entry.Category {
+			item.Categories = append(item.Categories, category.Term)
+		}
+		for _, link := range entry.Link {
+			if link.Rel == "enclosure" {
+				item.Enclosure = &RSSEnclosure{
+					URL:    link.resolve(base),
+					Type:   link.Type,
+					Length: link.Length,
+				}
+				break
+			}
+		}
+		if item.PubDate == "" {
+			item.PubDate = entry.Updated
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+	return &feed
+}
+
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Description string         `json:"description"`
+	Language    string         `json:"language"`
+	Icon        string         `json:"icon"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url"`
+	ExternalURL   string `json:"external_url"`
+	Title         string `json:"title"`
+	ContentHTML   string `json:"content_html"`
+	ContentText   string `json:"content_text"`
+	Summary       string `json:"summary"`
+	DatePublished string `json:"date_published"`
+	DateModified  string `json:"date_modified"`
+	Author        *JSONFeedAuthor `json:"author"`
+	Authors       []JSONFeedAuthor `json:"authors"`
+	Tags          []string `json:"tags"`
+	Attachments   []JSONFeedAttachment `json:"attachments"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type JSONFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes int64  `json:"size_in_bytes"`
+}
+
+func (j *JSONFeed) toRSS() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = j.Title
+	feed.Channel.Link = j.HomePageURL
+	feed.Channel.Description = j.Description
+	feed.Channel.Language = j.Language
+	feed.Channel.ImageURL = j.Icon
+	for _, entry := range j.Items {
+		item := RSSItem{
+			Title:       entry.Title,
+			Link:        entry.URL,
+			Description: entry.Summary,
+			PubDate:     entry.DatePublished,
+			GUID:        entry.ID,
+			ContentEncoded: entry.ContentHTML,
+			Categories:     entry.Tags,
+		}
+		var authors []string
+		for _, author := range entry.Authors {
+			authors = append(authors, author.Name)
+		}
+		if len(authors) == 0 && entry.Author != nil {
+			authors = append(authors, entry.Author.Name)
+		}
+		item.Author = strings.Join(authors, ", ")
+		if len(entry.Attachments) > 0 {
+			item.Enclosure = &RSSEnclosure{
+				URL:    entry.Attachments[0].URL,
+				Type:   entry.Attachments[0].MimeType,
+				Length: strconv.FormatInt(entry.Attachments[0].SizeInBytes, 10),
+			}
+		}
+		if item.Link == "" {
+			item.Link = entry.ExternalURL
+		}
+		if item.Description == "" {
+			item.Description = html.EscapeString(entry.ContentText)
+		}
+		if item.PubDate == "" {
+			item.PubDate = entry.DateModified
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+	return &feed
+}
+
+func isJSONFeed(body []byte, contentType string) bool {
+	if strings.Contains(contentType, "application/feed+json") || strings.Contains(contentType, "application/json") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+}
+
+func rootElement(body []byte) (string, error) {
+	decoder := newUTF8Decoder(body)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func parseFeed(body []byte, contentType string) (*RSSFeed, error) {
+	if isJSONFeed(body, contentType) {
+		var jsonFeed JSONFeed
+		if err := json.Unmarshal(body, &jsonFeed); err != nil {
+			return nil, fmt.Errorf("Error reading JSON feed: %w", err)
+		}
+		if !strings.Contains(jsonFeed.Version, "jsonfeed.org/version") {
+			return nil, fmt.Errorf("JSON document is not a JSON Feed, version is %q", jsonFeed.Version)
+		}
+		feed := jsonFeed.toRSS()
+		feed.Format = "JSON Feed"
+		return feed, nil
+	}
+	body, err := toUTF8(body, detectCharset(body, contentType))
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("User-Agent", "gator")
-	client := &http.Client{}
+	root, err := rootElement(body)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading feed document: %w", err)
+	}
+	switch root {
+	case "rss":
+		var feed RSSFeed
+		if err := newUTF8Decoder(body).Decode(&feed); err != nil {
+			return nil, err
+		}
+		feed.Format = "RSS"
+		return &feed, nil
+	case "feed":
+		var atom AtomFeed
+		if err := newUTF8Decoder(body).Decode(&atom); err != nil {
+			return nil, err
+		}
+		feed := atom.toRSS()
+		feed.Format = "Atom"
+		return feed, nil
+	default:
+		return nil, fmt.Errorf("Unsupported feed format, root element: <%s>", root)
+	}
+}
+
+var errNotModified = errors.New("Feed not modified since last fetch")
+
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("Unexpected response from %s: %s", e.URL, e.Status)
+}
+
+type cacheHeaders struct {
+	ETag         string
+	LastModified string
+}
+
+// fetchFeed does not wait for the host, callers do that first.
+func fetchFeed(ctx context.Context, feedURL string, cache cacheHeaders, limits fetchLimits) (*RSSFeed, cacheHeaders, error) {
+	req, err := newFetchRequest(ctx, feedURL)
+	if err != nil {
+		return nil, cache, err
+	}
+	if cache.ETag != "" {
+		req.Header.Set("If-None-Match", cache.ETag)
+	}
+	if cache.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cache.LastModified)
+	}
+	permanentURL := ""
+	onlyPermanent := true
+	client := &http.Client{
+		Timeout: limits.timeout,
+		CheckRedirect: func(next *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("Stopped after 10 redirects")
+			}
+			status := next.Response.StatusCode
+			if onlyPermanent && (status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect) {
+				permanentURL = next.URL.String()
+			} else {
+				onlyPermanent = false
+			}
+			return nil
+		},
+	}
 	res, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, cache, timeoutError(ctx, feedURL, limits, err)
 	}
 	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
+	if res.StatusCode == http.StatusNotModified {
+		return &RSSFeed{PermanentURL: permanentURL}, cache, errNotModified
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, cache, statusError(res, feedURL, limits)
+	}
+	newCache := cacheHeaders{
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	}
+	body, err := readLimitedBody(res, feedURL, limits)
 	if err != nil {
-		return nil, err
+		return nil, cache, err
 	}
-	var feed RSSFeed
-	if err := xml.Unmarshal(body, &feed); err != nil {
+	feed, err := parseFeed(body, res.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, cache, &notFeedError{
+			URL:         res.Request.URL.String(),
+			Body:        body,
+			ContentType: res.Header.Get("Content-Type"),
+			Err:         err,
+		}
+	}
+	feed.PermanentURL = permanentURL
+	resolveFeedLinks(feed, res.Request.URL.String())
+	cleanFeed(feed)
+	return feed, newCache, nil
+}
+
+func cleanFeed(feed *RSSFeed) {
+	channel := &feed.Channel
+	channel.Title = stripControls(html.UnescapeString(channel.Title))
+	channel.Description = sanitizeHTML(channel.Description)
+	channel.Link = stripControls(channel.Link)
+	channel.Language = stripControls(channel.Language)
+	channel.ImageURL = stripControls(channel.ImageURL)
+	for i := range channel.Item {
+		item := &channel.Item[i]
+		item.Title = stripControls(html.UnescapeString(item.Title))
+		item.Link = stripControls(item.Link)
+		item.Description = sanitizeHTML(item.Description)
+		item.ContentEncoded = sanitizeHTML(item.ContentEncoded)
+		item.Creator = stripControls(item.Creator)
+		item.Author = stripControls(item.Author)
+		item.Comments = stripControls(item.Comments)
+		for j := range item.Categories {
+			item.Categories[j] = stripControls(item.Categories[j])
+		}
+		if item.Enclosure != nil {
+			item.Enclosure.URL = stripControls(item.Enclosure.URL)
+			item.Enclosure.Type = stripControls(item.Enclosure.Type)
+			item.Enclosure.Length = stripControls(item.Enclosure.Length)
+		}
+	}
+}
+
+type notFeedError struct {
+	URL         string
+	Body        []byte
+	ContentType string
+	Err         error
+}
+
+func (e *notFeedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *notFeedError) Unwrap() error {
+	return e.Err
+}
+
+func fetchNewFeed(ctx context.Context, feedURL string, limits fetchLimits) (*RSSFeed, error) {
+	if err := limits.hosts.wait(ctx, feedURL); err != nil {
 		return nil, err
 	}
-	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
-	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
-	for i, _ := range feed.Channel.Item {
-		feed.Channel.Item[i].Title = html.UnescapeString(feed.Channel.Item[i].Title)
-		feed.Channel.Item[i].Description = html.UnescapeString(feed.Channel.Item[i].Description)
+	feed, _, err := fetchFeed(ctx, feedURL, cacheHeaders{}, limits)
+	return feed, err
+}
+
+type feedError struct {
+	Feed    database.Feed
+	Err     error
+	RetryIn time.Duration
+}
+
+func (e *feedError) Error() string {
+	return fmt.Sprintf("Feed %s: %v", e.Feed.Name, e.Err)
+}
+
+func (e *feedError) Unwrap() error {
+	return e.Err
+}
+
+func failureBackoff(failures int32) time.Duration {
+	if failures > 10 {
+		failures = 10
+	}
+	backoff := time.Minute << failures
+	if backoff > 24*time.Hour {
+		backoff = 24 * time.Hour
+	}
+	return backoff
+}
+
+type aggOptions struct {
+	workers     int
+	batch       int
+	feedTimeout time.Duration
+	instanceID  string
+	download    bool
+}
+
+func (opts aggOptions) leaseDuration(hosts *hostLimiter) time.Duration {
+	rounds := (opts.batch + opts.workers - 1) / opts.workers
+	return time.Duration(rounds)*opts.feedTimeout + time.Duration(opts.batch)*hosts.spacing() + time.Minute
+}
+
+type aggStats struct {
+	cycles      atomic.Int64
+	succeeded   atomic.Int64
+	notModified atomic.Int64
+	failed      atomic.Int64
+	deferred    atomic.Int64
+	canceled    atomic.Int64
+	posts       atomic.Int64
+	updated     atomic.Int64
+	downloads   atomic.Int64
+	articles    atomic.Int64
+}
+
+func (st *aggStats) print(elapsed time.Duration) {
+	fmt.Printf("Aggregation stopped after %v and %d cycles.\n", elapsed.Round(time.Second), st.cycles.Load())
+	fmt.Printf("Feeds fetched: %d, not modified: %d, failed: %d, deferred: %d, canceled: %d\n", st.succeeded.Load(), st.notModified.Load(), st.failed.Load(), st.deferred.Load(), st.canceled.Load())
+	fmt.Printf("Posts saved: %d, updated: %d, enclosures downloaded: %d, articles extracted: %d\n", st.posts.Load(), st.updated.Load(), st.downloads.Load(), st.articles.Load())
+}
+
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "gator"
 	}
-	return &feed, nil
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8])
 }
 
-func scrapeFeeds(ctx context.Context, s *state) error {
-	feed, err := s.db.GetNextFeedToFetch(ctx)
+func scrapeFeeds(ctx context.Context, s *state, opts aggOptions, stats *aggStats) error {
+	stats.cycles.Add(1)
+	dbCtx := context.WithoutCancel(ctx)
+	recovered, err := s.db.ReleaseExpiredLeases(dbCtx, sql.NullTime{
+		Time:  time.Now(),
+		Valid: true,
+	})
 	if err != nil {
 		return err
 	}
-	params := database.MarkFeedFetchedParams{
+	if recovered > 0 {
+		log.Printf("Recovered %d feeds whose lease expired mid-fetch", recovered)
+	}
+	owner := sql.NullString{
+		String: opts.instanceID,
+		Valid:  true,
+	}
+	feeds, err := s.db.ClaimFeedsToFetch(ctx, database.ClaimFeedsToFetchParams{
 		LastFetchedAt: sql.NullTime{
 			Time:  time.Now(),
 			Valid: true,
 		},
-		ID: feed.ID,
-	}
-	err = s.db.MarkFeedFetched(ctx, params)
+		LeaseOwner: owner,
+		LeaseExpiresAt: sql.NullTime{
+			Time:  time.Now().Add(opts.leaseDuration(s.hosts)),
+			Valid: true,
+		},
+		Limit: int32(opts.batch),
+	})
 	if err != nil {
 		return err
 	}
+	if len(feeds) == 0 {
+		fmt.Println("No feeds due for fetching.")
+		return nil
+	}
+	jobs := make(chan database.Feed)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var dbErr error
+	for i := 0; i < opts.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feed := range jobs {
+				var err error
+				if ctx.Err() != nil {
+					stats.canceled.Add(1)
+				} else {
+					feed, err = processFeed(ctx, s, feed, opts.feedTimeout, stats)
+				}
+				releaseErr := s.db.ReleaseFeedLease(dbCtx, database.ReleaseFeedLeaseParams{
+					ID:         feed.ID,
+					LeaseOwner: owner,
+				})
+				if err == nil {
+					err = releaseErr
+				}
+				var feedErr *feedError
+				if errors.As(err, &feedErr) {
+					log.Printf("%v, retrying in %v", feedErr, feedErr.RetryIn)
+				} else if err != nil {
+					mu.Lock()
+					if dbErr == nil {
+						dbErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, feed := range feeds {
+		jobs <- feed
+	}
+	close(jobs)
+	wg.Wait()
+	if dbErr == nil && opts.download {
+		downloaded, err := downloadPending(ctx, s, uuid.NullUUID{}, int32(opts.batch))
+		stats.downloads.Add(int64(downloaded))
+		if err != nil {
+			return err
+		}
+	}
+	if dbErr == nil && ctx.Err() == nil {
+		extracted, err := extractPending(ctx, s, int32(s.config.ArticleBatchSize()))
+		stats.articles.Add(int64(extracted))
+		if err != nil {
+			return err
+		}
+	}
+	return dbErr
+}
+
+// processFeed returns the feed that survives a merge.
+func processFeed(ctx context.Context, s *state, feed database.Feed, timeout time.Duration, stats *aggStats) (database.Feed, error) {
+	if err := s.hosts.wait(ctx, feed.Url); err != nil {
+		var paused *HostPausedError
+		if errors.As(err, &paused) {
+			stats.deferred.Add(1)
+			fmt.Printf("Feed %s deferred, %v\n", feed.Name, paused)
+			return feed, s.db.DeferFeedFetch(context.WithoutCancel(ctx), database.DeferFeedFetchParams{
+				NextFetchAt: sql.NullTime{
+					Time:  paused.Until,
+					Valid: true,
+				},
+				ID: feed.ID,
+			})
+		}
+		if ctx.Err() != nil {
+			stats.canceled.Add(1)
+			return feed, nil
+		}
+	}
+	feedCtx, cancel := context.WithTimeoutCause(ctx, timeout, &FeedDeadlineError{URL: feed.Url, Timeout: timeout})
+	defer cancel()
+	result, scrapeErr := scrapeFeed(feedCtx, s, feed)
+	feed = result.feed
+	stats.posts.Add(int64(result.saved))
+	stats.updated.Add(int64(result.updated))
+	notModified := errors.Is(scrapeErr, errNotModified)
+	if notModified {
+		stats.notModified.Add(1)
+		scrapeErr = nil
+	}
+	dbCtx := context.WithoutCancel(ctx)
+	if scrapeErr != nil && ctx.Err() != nil {
+		stats.canceled.Add(1)
+		return feed, nil
+	}
+	var storeErr *storeError
+	if errors.As(scrapeErr, &storeErr) {
+		return feed, storeErr
+	}
+	if scrapeErr != nil {
+		stats.failed.Add(1)
+		backoff := failureBackoff(feed.ConsecutiveFailures + 1)
+		var statusErr *HTTPStatusError
+		if errors.As(scrapeErr, &statusErr) && statusErr.RetryAfter > backoff {
+			backoff = statusErr.RetryAfter
+		}
+		err := s.db.RecordFeedFailure(dbCtx, database.RecordFeedFailureParams{
+			LastError: sql.NullString{
+				String: scrapeErr.Error(),
+				Valid:  true,
+			},
+			LastErrorAt: sql.NullTime{
+				Time:  time.Now(),
+				Valid: true,
+			},
+			NextFetchAt: sql.NullTime{
+				Time:  time.Now().Add(backoff),
+				Valid: true,
+			},
+			ID: feed.ID,
+		})
+		if err != nil {
+			return feed, err
+		}
+		return feed, &feedError{Feed: feed, Err: scrapeErr, RetryIn: backoff}
+	}
+	if !notModified {
+		stats.succeeded.Add(1)
+	}
+	return feed, s.db.RecordFeedSuccess(dbCtx, database.RecordFeedSuccessParams{
+		LastSuccessAt: sql.NullTime{
+			Time:  time.Now(),
+			Valid: true,
+		},
+		NextFetchAt:          result.nextFetchAt,
+		FetchIntervalSeconds: result.interval,
+		ID:                   feed.ID,
+	})
+}
+
+type FeedDeadlineError struct {
+	URL     string
+	Timeout time.Duration
+}
+
+func (e *FeedDeadlineError) Error() string {
+	return fmt.Sprintf("Fetching %s took longer than the agg --timeout of %s", e.URL, e.Timeout)
+}
+
+type scrapeResult struct {
+	feed        database.Feed
+	saved       int
+	updated     int
+	nextFetchAt sql.NullTime
+	interval    sql.NullInt32
+}
+
+// moveFeed merges the feed into any feed that already has the new URL.
+func moveFeed(ctx context.Context, s *state, feed database.Feed, newURL string) (database.Feed, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return feed, err
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+	target, err := qtx.GetFeedsByURLS(ctx, newURL)
+	event := "merged"
+	if errors.Is(err, sql.ErrNoRows) {
+		event = "moved"
+		target = feed
+		err = qtx.UpdateFeedURL(ctx, database.UpdateFeedURLParams{
+			Url:       newURL,
+			UpdatedAt: time.Now(),
+			ID:        feed.ID,
+		})
+		target.Url = newURL
+	} else if err == nil {
+		err = qtx.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{
+			TargetFeedID: target.ID,
+			SourceFeedID: feed.ID,
+		})
+		if err == nil {
+			err = qtx.MoveFeedHistory(ctx, database.MoveFeedHistoryParams{
+				TargetFeedID: target.ID,
+				SourceFeedID: feed.ID,
+			})
+		}
+		if err == nil {
+			err = qtx.MovePosts(ctx, database.MovePostsParams{
+				TargetFeedID: target.ID,
+				SourceFeedID: feed.ID,
+			})
+		}
+		if err == nil {
+			err = qtx.DeleteFeed(ctx, feed.ID)
+		}
+	}
+	if err != nil {
+		return feed, err
+	}
+	err = qtx.CreateFeedHistory(ctx, database.CreateFeedHistoryParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		FeedID:    target.ID,
+		Event:     event,
+		OldUrl:    feed.Url,
+		NewUrl:    newURL,
+	})
+	if err != nil {
+		return feed, err
+	}
+	if err := tx.Commit(); err != nil {
+		return feed, err
+	}
+	log.Printf("Feed %s %s from %s to %s", feed.Name, event, feed.Url, newURL)
+	return target, nil
+}
+
+func scrapeFeed(ctx context.Context, s *state, feed database.Feed) (scrapeResult, error) {
+	var result scrapeResult
 	fmt.Printf("Fetching feed: %s\n", feed.Name)
-	realFeed, err := fetchFeed(ctx, feed.Url)
+	cache := cacheHeaders{
+		ETag:         feed.Etag.String,
+		LastModified: feed.LastModified.String,
+	}
+	result.feed = feed
+	realFeed, newCache, err := fetchFeed(ctx, feed.Url, cache, newFetchLimits(s))
+	if errors.Is(err, errNotModified) {
+		fmt.Printf("Feed %s not modified, skipping.\n", feed.Name)
+		if realFeed.PermanentURL != "" && realFeed.PermanentURL != feed.Url {
+			moved, moveErr := moveFeed(context.WithoutCancel(ctx), s, feed, realFeed.PermanentURL)
+			if moveErr != nil {
+				return result, &storeError{Err: moveErr}
+			}
+			result.feed = moved
+		}
+		result.nextFetchAt, result.interval = planNextFetch(result.feed, nil, time.Now())
+		return result, err
+	}
 	if err != nil {
-		return err
+		return result, err
+	}
+	result, err = storeFeed(context.WithoutCancel(ctx), s, feed, realFeed, newCache)
+	if err != nil {
+		return result, &storeError{Err: err}
+	}
+	return result, nil
+}
+
+// storeError failures do not count against the feed.
+type storeError struct {
+	Err error
+}
+
+func (e *storeError) Error() string {
+	return fmt.Sprintf("Error saving feed: %v", e.Err)
+}
+
+func (e *storeError) Unwrap() error {
+	return e.Err
+}
+
+func storeFeed(ctx context.Context, s *state, feed database.Feed, realFeed *RSSFeed, newCache cacheHeaders) (scrapeResult, error) {
+	result := scrapeResult{feed: feed}
+	var err error
+	if realFeed.PermanentURL != "" && realFeed.PermanentURL != feed.Url {
+		feed, err = moveFeed(ctx, s, feed, realFeed.PermanentURL)
+		if err != nil {
+			return result, err
+		}
+		result.feed = feed
+	}
+	err = s.db.UpdateFeedMetadata(ctx, database.UpdateFeedMetadataParams{
+		Title:       optionalString(strings.TrimSpace(realFeed.Channel.Title)),
+		Description: optionalString(strings.TrimSpace(realFeed.Channel.Description)),
+		SiteLink:    optionalString(strings.TrimSpace(realFeed.Channel.Link)),
+		Language:    optionalString(strings.TrimSpace(realFeed.Channel.Language)),
+		ImageUrl:    optionalString(strings.TrimSpace(realFeed.Channel.ImageURL)),
+		SkipHours:   trimmedStrings(realFeed.Channel.SkipHours),
+		SkipDays:    trimmedStrings(realFeed.Channel.SkipDays),
+		UpdatedAt:   time.Now(),
+		ID:          feed.ID,
+	})
+	if err != nil {
+		return result, err
 	}
 	fmt.Printf("Saving %v posts.\n", realFeed.Channel.Title)
 	var params2 database.CreatePostParams
-	for _, item := range realFeed.Channel.Item {
-		publishedTime, err := time.Parse("Mon, 02 Jan 2006 15:04:05 MST", item.PubDate)
+	var dateFailures int32
+	var lastDateErr error
+	guids := itemGUIDs(realFeed.Channel.Item)
+	for i, item := range realFeed.Channel.Item {
+		dateMissing := false
+		publishedTime, err := parsePubDate(item.PubDate)
 		if err != nil {
-			log.Printf("Failed to parse PubDate for item: %s, error: %v", item.Title, err)
-			continue // Skip this item and move to the next
+			log.Printf("Failed to parse PubDate for item: %s, using first-seen time, error: %v", item.Title, err)
+			dateFailures++
+			lastDateErr = err
+			dateMissing = true
+			publishedTime = time.Now()
 		}
 		params2 = database.CreatePostParams{
 			ID:        uuid.New(),
@@ -127,7 +1001,7 @@ func scrapeFeeds(ctx context.Context, s *state) error {
 				String: item.Title,
 				Valid:  true,
 			},
-			Url:	   item.Link,
+			Url:	   itemURL(item),
 			Description: sql.NullString{
 				String: item.Description,
 				Valid:  true,
@@ -137,13 +1011,182 @@ func scrapeFeeds(ctx context.Context, s *state) error {
 				Valid: true,
 			},
 			FeedID:	   feed.ID,
+			Guid:	   guids[i],
+			ContentHash: sql.NullString{
+				String: itemContentHash(item),
+				Valid:  true,
+			},
+			ContentHtml: optionalString(item.ContentEncoded),
+			Author:      optionalString(itemAuthor(item)),
+			Categories:  itemCategories(item),
+			CommentsUrl: optionalString(strings.TrimSpace(item.Comments)),
+		}
+		if item.Enclosure != nil && item.Enclosure.URL != "" {
+			params2.EnclosureUrl = optionalString(item.Enclosure.URL)
+			params2.EnclosureType = optionalString(item.Enclosure.Type)
+			if length, err := strconv.ParseInt(strings.TrimSpace(item.Enclosure.Length), 10, 64); err == nil && length > 0 {
+				params2.EnclosureLength = sql.NullInt64{
+					Int64: length,
+					Valid: true,
+				}
+			}
 		}
-		_, err = s.db.CreatePost(ctx, params2)
+		outcome, err := savePost(ctx, s, params2, item.RawLink, dateMissing)
 		if err != nil {
-			return err
+			return result, err
+		}
+		switch outcome {
+		case postCreated:
+			result.saved++
+		case postUpdated:
+			result.updated++
 		}
 	}
-	return nil
+	err = s.db.UpdateFeedCacheHeaders(ctx, database.UpdateFeedCacheHeadersParams{
+		Etag: sql.NullString{
+			String: newCache.ETag,
+			Valid:  newCache.ETag != "",
+		},
+		LastModified: sql.NullString{
+			String: newCache.LastModified,
+			Valid:  newCache.LastModified != "",
+		},
+		ID: feed.ID,
+	})
+	if err != nil {
+		return result, err
+	}
+	if dateFailures > 0 {
+		err = s.db.RecordDateParseFailures(ctx, database.RecordDateParseFailuresParams{
+			DateParseFailures: dateFailures,
+			LastDateParseError: sql.NullString{
+				String: lastDateErr.Error(),
+				Valid:  true,
+			},
+			UpdatedAt: time.Now(),
+			ID:        feed.ID,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	result.nextFetchAt, result.interval = planNextFetch(feed, realFeed, time.Now())
+	return result, nil
+}
+
+func itemContentHash(item RSSItem) string {
+	fields := []string{item.Title, item.Link, item.Description, item.PubDate, item.ContentEncoded, itemAuthor(item), strings.Join(item.Categories, ","), item.Comments}
+	if item.Enclosure != nil {
+		fields = append(fields, item.Enclosure.URL)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+type postOutcome int
+
+const (
+	postUnchanged postOutcome = iota
+	postCreated
+	postUpdated
+)
+
+// adoptLegacyPost gives a post stored under an older key the item's current one.
+func adoptLegacyPost(ctx context.Context, s *state, params database.CreatePostParams, urls []string) (database.Post, error) {
+	var guids []string
+	for _, url := range urls {
+		guids = append(guids, url, hashKey(url))
+	}
+	legacy, err := s.db.GetLegacyPost(ctx, database.GetLegacyPostParams{
+		FeedID: params.FeedID,
+		Guids:  guids,
+	})
+	if err != nil {
+		return legacy, err
+	}
+	err = s.db.UpdatePostGUID(ctx, database.UpdatePostGUIDParams{
+		Guid: params.Guid,
+		ID:   legacy.ID,
+	})
+	if err != nil {
+		return legacy, err
+	}
+	legacy.Guid = params.Guid
+	return legacy, nil
+}
+
+func savePost(ctx context.Context, s *state, params database.CreatePostParams, rawLink string, dateMissing bool) (postOutcome, error) {
+	existing, err := s.db.GetPostByFeedGUID(ctx, database.GetPostByFeedGUIDParams{
+		FeedID: params.FeedID,
+		Guid:   params.Guid,
+	})
+	var urls []string
+	for _, candidate := range []string{params.Url, rawLink} {
+		if candidate != "" && !containsString(urls, candidate) {
+			urls = append(urls, candidate)
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) && len(urls) > 0 {
+		existing, err = adoptLegacyPost(ctx, s, params, urls)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = s.db.CreatePost(ctx, params)
+		if errors.Is(err, sql.ErrNoRows) {
+			return postUnchanged, nil
+		}
+		if err != nil {
+			return postUnchanged, err
+		}
+		return postCreated, nil
+	}
+	if err != nil {
+		return postUnchanged, err
+	}
+	if existing.ContentHash.String == params.ContentHash.String {
+		return postUnchanged, nil
+	}
+	if existing.ContentHash.Valid && s.config.KeepPostRevisions {
+		err = s.db.CreatePostRevision(ctx, database.CreatePostRevisionParams{
+			ID:          uuid.New(),
+			CreatedAt:   time.Now(),
+			PostID:      existing.ID,
+			Title:       existing.Title,
+			Url:         existing.Url,
+			Description: existing.Description,
+			PublishedAt: existing.PublishedAt,
+			ContentHash: existing.ContentHash,
+		})
+		if err != nil {
+			return postUnchanged, err
+		}
+	}
+	publishedAt := params.PublishedAt
+	if dateMissing {
+		publishedAt = existing.PublishedAt
+	}
+	err = s.db.UpdatePost(ctx, database.UpdatePostParams{
+		Title:       params.Title,
+		Url:         params.Url,
+		Description: params.Description,
+		PublishedAt: publishedAt,
+		ContentHash: params.ContentHash,
+		UpdatedAt:   time.Now(),
+		ContentHtml:     params.ContentHtml,
+		Author:          params.Author,
+		Categories:      params.Categories,
+		CommentsUrl:     params.CommentsUrl,
+		EnclosureUrl:    params.EnclosureUrl,
+		EnclosureType:   params.EnclosureType,
+		EnclosureLength: params.EnclosureLength,
+		ID:          existing.ID,
+	})
+	if err != nil {
+		return postUnchanged, err
+	}
+	if !existing.ContentHash.Valid {
+		return postUnchanged, nil
+	}
+	return postUpdated, nil
 }
 
 func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
@@ -236,35 +1279,112 @@ func handlerUsers(s *state, cmd command) error {
 	return nil
 }
 
+const maxDBFailures = 5
+
 func handlerAgg(s *state, cmd command) error {
-	if len(cmd.args) != 1 {
+	if len(cmd.args) < 1 {
 		return fmt.Errorf("Need to be given how much time between grabbing a new feed.")
 	}
 	time_between_reqs, err := time.ParseDuration(cmd.args[0])
 	if err != nil {
 		return fmt.Errorf("Error with translating given string, possible incorrect syntax. Error: %v", err)
 	}
+	flags := flag.NewFlagSet("agg", flag.ContinueOnError)
+	workers := flags.Int("workers", 1, "number of feeds fetched in parallel")
+	batch := flags.Int("batch", 1, "number of stale feeds claimed each cycle")
+	feedTimeout := flags.Duration("timeout", time.Minute, "time limit for fetching a single feed")
+	download := flags.Bool("download", false, "download new podcast enclosures after each cycle")
+	if err := flags.Parse(cmd.args[1:]); err != nil {
+		return err
+	}
+	if *workers <= 0 || *batch <= 0 || *feedTimeout <= 0 {
+		return fmt.Errorf("Error: --workers, --batch and --timeout must be positive.")
+	}
+	opts := aggOptions{
+		workers:     *workers,
+		batch:       *batch,
+		feedTimeout: *feedTimeout,
+		instanceID:  newInstanceID(),
+		download:    *download,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	stats := &aggStats{}
+	started := time.Now()
+	defer func() {
+		stats.print(time.Since(started))
+	}()
 	ticker := time.NewTicker(time_between_reqs)
-	fmt.Printf("Collecting feeds every %v\n", time_between_reqs)
-	for ; ; <-ticker.C {
-		forErr := scrapeFeeds(context.Background(), s)
-		if forErr != nil {
-			return fmt.Errorf("Error while getting feeds, Error: %v", forErr)
+	defer ticker.Stop()
+	fmt.Printf("Collecting %d feeds every %v with %d workers as %s\n", opts.batch, time_between_reqs, opts.workers, opts.instanceID)
+	dbFailures := 0
+	for {
+		forErr := scrapeFeeds(ctx, s, opts, stats)
+		if ctx.Err() != nil {
+			fmt.Println("Shutting down, in-progress fetches have finished.")
+			return nil
+		}
+		if forErr == nil {
+			dbFailures = 0
+		} else {
+			dbFailures++
+			log.Printf("Database error while getting feeds (%d/%d): %v", dbFailures, maxDBFailures, forErr)
+			if dbFailures >= maxDBFailures {
+				return fmt.Errorf("Error while getting feeds, Error: %v", forErr)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			fmt.Println("Shutting down.")
+			return nil
+		case <-ticker.C:
 		}
 	}
-	return nil
 }
 
 func handlerAddFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.args) != 2 {
+	force := false
+	var args []string
+	for _, arg := range cmd.args {
+		if arg == "--force" || arg == "-force" {
+			force = true
+		} else {
+			args = append(args, arg)
+		}
+	}
+	if len(args) != 1 && len(args) != 2 {
 		return fmt.Errorf("Error, either too many arguments or not enough arguments.")
 	}
+	name := ""
+	if len(args) == 2 {
+		name = args[0]
+	}
+	feedURL, trial, err := resolveFeedURL(context.Background(), args[len(args)-1], newFetchLimits(s))
+	if err != nil {
+		if !force {
+			return fmt.Errorf("Error finding feed: %v\nUse --force to add it anyway.", err)
+		}
+		feedURL = args[len(args)-1]
+		fmt.Printf("Warning: %s is not a parseable feed: %v\n", feedURL, err)
+	} else {
+		fmt.Printf("Detected %s feed with %d items\n", trial.Format, len(trial.Channel.Item))
+		if trial.PermanentURL != "" {
+			fmt.Printf("Feed has permanently moved to %v\n", trial.PermanentURL)
+			feedURL = trial.PermanentURL
+		}
+		if name == "" {
+			name = strings.TrimSpace(trial.Channel.Title)
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("Error: The feed has no title, a name for it is required.")
+	}
 	params := database.CreateFeedParams{
         ID:        uuid.New(),
         CreatedAt: time.Now(),
         UpdatedAt: time.Now(),
-        Name:      cmd.args[0],
-		Url:	   cmd.args[1],
+        Name:      name,
+		Url:	   feedURL,
 		UserID:	   user.ID,
     }
 	feed, err := s.db.CreateFeed(context.Background(), params)
@@ -299,7 +1419,69 @@ func handlerFeeds(s *state, cmd command) error {
 		if err != nil {
 			return fmt.Errorf("Error getting username: %w", err)
 		}
-		fmt.Printf("%v | %v | %v\n", feed.Name, feed.Url, user.Name)
+		if feed.ConsecutiveFailures > 0 {
+			fmt.Printf("%v | %v | %v | failing (%d): %v\n", feed.Name, feed.Url, user.Name, feed.ConsecutiveFailures, feed.LastError.String)
+		} else {
+			fmt.Printf("%v | %v | %v\n", feed.Name, feed.Url, user.Name)
+		}
+		if feed.Title.Valid && feed.Title.String != feed.Name {
+			fmt.Printf("	%v\n", feed.Title.String)
+		}
+		if feed.SiteLink.Valid {
+			fmt.Printf("	%v\n", feed.SiteLink.String)
+		}
+	}
+	return nil
+}
+
+func formatOptionalTime(t sql.NullTime) string {
+	if !t.Valid {
+		return "never"
+	}
+	return t.Time.Format(time.RFC1123)
+}
+
+func handlerFeedInfo(s *state, cmd command) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("Either a feed URL is needed or too many were given.")
+	}
+	feed, err := s.db.GetFeedsByURLS(context.Background(), cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("Error getting feed via URL from table: %w", err)
+	}
+	user, err := s.db.GetUserByID(context.Background(), feed.UserID)
+	if err != nil {
+		return fmt.Errorf("Error getting username: %w", err)
+	}
+	fmt.Printf("Name:          %v\n", feed.Name)
+	fmt.Printf("URL:           %v\n", feed.Url)
+	fmt.Printf("Added by:      %v\n", user.Name)
+	fmt.Printf("Title:         %v\n", feed.Title.String)
+	fmt.Printf("Description:   %v\n", htmlToText(feed.Description.String))
+	fmt.Printf("Site:          %v\n", feed.SiteLink.String)
+	fmt.Printf("Language:      %v\n", feed.Language.String)
+	fmt.Printf("Image:         %v\n", feed.ImageUrl.String)
+	fmt.Printf("Last fetched:  %v\n", formatOptionalTime(feed.LastFetchedAt))
+	fmt.Printf("Last success:  %v\n", formatOptionalTime(feed.LastSuccessAt))
+	fmt.Printf("Next fetch:    %v\n", formatOptionalTime(feed.NextFetchAt))
+	if feed.AdaptiveInterval {
+		fmt.Printf("Interval:      adaptive (currently %v)\n", time.Duration(feed.FetchIntervalSeconds.Int32)*time.Second)
+	} else if feed.FetchIntervalSeconds.Valid {
+		fmt.Printf("Interval:      %v\n", time.Duration(feed.FetchIntervalSeconds.Int32)*time.Second)
+	}
+	if feed.FetchFullContent {
+		fmt.Printf("Full content:  fetched from each post's page\n")
+	}
+	if feed.ConsecutiveFailures > 0 {
+		fmt.Printf("Failing:       %d times in a row, last at %v\n", feed.ConsecutiveFailures, formatOptionalTime(feed.LastErrorAt))
+		fmt.Printf("Last error:    %v\n", feed.LastError.String)
+	}
+	history, err := s.db.GetFeedHistory(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting feed history: %w", err)
+	}
+	for _, entry := range history {
+		fmt.Printf("%v: %v from %v to %v\n", entry.CreatedAt.Format(time.RFC1123), entry.Event, entry.OldUrl, entry.NewUrl)
 	}
 	return nil
 }
@@ -337,7 +1519,11 @@ func handlerFollowing(s *state, cmd command, user database.User) error {
 	}
 	fmt.Printf("%v is following these feeds:\n", user.Name)
 	for _, feed := range feeds_followed {
-		fmt.Printf("%v\n", feed.FeedName)
+		if feed.Folder.Valid {
+			fmt.Printf("%v (%v)\n", feed.FeedName, feed.Folder.String)
+		} else {
+			fmt.Printf("%v\n", feed.FeedName)
+		}
 	}
 	return nil
 }
@@ -361,13 +1547,124 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 	return nil
 }
 
-func handlerBrowse(s *state, cmd command, user database.User) error {
-	if len(cmd.args) >= 2 {
-		return fmt.Errorf("Error: Command accepts at most one argument, which must be a postive whole number.")
+func handlerSetInterval(s *state, cmd command) error {
+	if len(cmd.args) != 2 {
+		return fmt.Errorf("Need a feed URL and either a duration like 30m or 'auto'.")
+	}
+	feed, err := s.db.GetFeedsByURLS(context.Background(), cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("Error getting feed via URL from table: %w", err)
+	}
+	params := database.SetFeedIntervalParams{
+		UpdatedAt: time.Now(),
+		ID:        feed.ID,
+	}
+	if cmd.args[1] == "auto" {
+		params.AdaptiveInterval = true
+	} else {
+		interval, err := time.ParseDuration(cmd.args[1])
+		if err != nil {
+			return fmt.Errorf("Error with translating given string, possible incorrect syntax. Error: %v", err)
+		}
+		if interval < time.Second {
+			return fmt.Errorf("Error: Interval must be at least one second.")
+		}
+		if interval/time.Second > math.MaxInt32 {
+			return fmt.Errorf("Error: Interval can be at most %v.", time.Duration(math.MaxInt32)*time.Second)
+		}
+		params.FetchIntervalSeconds = sql.NullInt32{
+			Int32: int32(interval / time.Second),
+			Valid: true,
+		}
+	}
+	err = s.db.SetFeedInterval(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("Error setting feed interval: %w", err)
+	}
+	if params.AdaptiveInterval {
+		fmt.Printf("%v will now be fetched on an adaptive schedule\n", feed.Name)
+	} else {
+		fmt.Printf("%v will now be fetched every %v\n", feed.Name, cmd.args[1])
+	}
+	return nil
+}
+
+func handlerFullContent(s *state, cmd command) error {
+	if len(cmd.args) != 2 || (cmd.args[1] != "on" && cmd.args[1] != "off") {
+		return fmt.Errorf("Need a feed URL and either 'on' or 'off'.")
+	}
+	feed, err := s.db.GetFeedsByURLS(context.Background(), cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("Error getting feed via URL from table: %w", err)
+	}
+	since := sql.NullTime{}
+	if cmd.args[1] == "on" {
+		since = feed.FullContentSince
+		if !feed.FetchFullContent || !since.Valid {
+			since = sql.NullTime{
+				Time:  time.Now(),
+				Valid: true,
+			}
+		}
+	}
+	err = s.db.SetFeedFullContent(context.Background(), database.SetFeedFullContentParams{
+		FetchFullContent: cmd.args[1] == "on",
+		FullContentSince: since,
+		UpdatedAt:        time.Now(),
+		ID:               feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("Error setting full content mode: %w", err)
+	}
+	if cmd.args[1] == "on" {
+		fmt.Printf("Full articles will be fetched for new posts from %v\n", feed.Name)
+	} else {
+		fmt.Printf("Only the feed's own content will be kept for %v\n", feed.Name)
+	}
+	return nil
+}
+
+func handlerRevisions(s *state, cmd command) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("Either a post URL is needed or too many were given.")
+	}
+	posts, err := s.db.GetPostsByURL(context.Background(), normalizeURL(cmd.args[0]))
+	if err == nil && len(posts) == 0 && normalizeURL(cmd.args[0]) != cmd.args[0] {
+		posts, err = s.db.GetPostsByURL(context.Background(), cmd.args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("Error getting posts via URL from table: %w", err)
+	}
+	if len(posts) == 0 {
+		return fmt.Errorf("No post found with URL %s", cmd.args[0])
 	}
+	for _, post := range posts {
+		fmt.Printf("Current (updated %v): %v\n", post.UpdatedAt.Format(time.RFC1123), post.Title.String)
+		revisions, err := s.db.GetPostRevisions(context.Background(), post.ID)
+		if err != nil {
+			return fmt.Errorf("Error getting revisions for post: %w", err)
+		}
+		if len(revisions) == 0 {
+			fmt.Printf("	No earlier revisions recorded.\n")
+		}
+		for _, revision := range revisions {
+			fmt.Printf("	Replaced %v: %v\n", revision.CreatedAt.Format(time.RFC1123), revision.Title.String)
+			if revision.Description.String != post.Description.String {
+				fmt.Printf("		Description was: %v\n", htmlToText(revision.Description.String))
+			}
+			if !revision.PublishedAt.Time.Equal(post.PublishedAt.Time) {
+				fmt.Printf("		Published date was: %v\n", revision.PublishedAt.Time.Format(time.RFC1123))
+			}
+		}
+	}
+	return nil
+}
+
+func handlerBrowse(s *state, cmd command, user database.User) error {
+	args := cmd.args
 	var limit int32
-	if len(cmd.args) == 1 {
-		parsedLimit, err := strconv.Atoi(cmd.args[0])
+	if len(args) >= 1 && !strings.HasPrefix(args[0], "-") {
+		parsedLimit, err := strconv.Atoi(args[0])
     	if err != nil {
         	return fmt.Errorf("Error: Limit must be a whole number.")
     	}
@@ -375,12 +1672,23 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 			return fmt.Errorf("Error: Limit must be a positive whole number.")
 		}
 		limit = int32(parsedLimit)
+		args = args[1:]
 	} else {
 		limit = 2
 	}
+	flags := flag.NewFlagSet("browse", flag.ContinueOnError)
+	category := flags.String("category", "", "only show posts tagged with this category")
+	full := flags.Bool("full", false, "show the extracted article instead of the description when there is one")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("Error: Command accepts at most one argument, which must be a postive whole number.")
+	}
 	params := database.GetPostsByUserParams{
 		UserID:	user.ID,
-		Limit:	limit,
+		Category: optionalString(*category),
+		LimitCount:	limit,
 	}
 	userPosts, err := s.db.GetPostsByUser(context.Background(), params)
 	if err != nil {
@@ -393,10 +1701,29 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 	fmt.Printf("Now browsing the latest %d posts:\n", limit)
 	for _, post := range userPosts {
 		if post.Title.Valid {
-			fmt.Printf("	-%v\n\n", post.Title.String)
+			fmt.Printf("	-%v\n", post.Title.String)
 		} else {
-			fmt.Printf("	-No Title\n\n")
+			fmt.Printf("	-No Title\n")
+		}
+		if post.Author.Valid {
+			fmt.Printf("	 by %v\n", post.Author.String)
+		}
+		if len(post.Categories) > 0 {
+			fmt.Printf("	 [%v]\n", strings.Join(post.Categories, ", "))
+		}
+		if post.EnclosureUrl.Valid {
+			fmt.Printf("	 Attachment (%v): %v\n", post.EnclosureType.String, post.EnclosureUrl.String)
+		}
+		body := post.Description
+		if *full && post.Content.Valid {
+			body = post.Content
+		}
+		if body.Valid {
+			for _, line := range renderHTMLText(body.String, terminalWidth()-9) {
+				fmt.Printf("	 %v\n", line)
+			}
 		}
+		fmt.Println()
 	}
 	return nil
 }
@@ -414,7 +1741,9 @@ func main() {
 	dbQueries := database.New(db)
 	s := &state{
 		db:		dbQueries,
+		conn:	db,
 		config: &configInfo,
+		hosts:  newHostLimiter(&configInfo),
 	}
 	cmds := &commands{
 		handlers: make(map[string]func(*state, command) error),
@@ -426,10 +1755,18 @@ func main() {
 	cmds.register("agg", handlerAgg)
 	cmds.register("addfeed", middlewareLoggedIn(handlerAddFeed))
 	cmds.register("feeds", handlerFeeds)
+	cmds.register("feedinfo", handlerFeedInfo)
 	cmds.register("follow", middlewareLoggedIn(handlerFollow))
 	cmds.register("following", middlewareLoggedIn(handlerFollowing))
 	cmds.register("unfollow", middlewareLoggedIn(handlerUnfollow))
 	cmds.register("browse", middlewareLoggedIn(handlerBrowse))
+	cmds.register("setinterval", handlerSetInterval)
+	cmds.register("fullcontent", handlerFullContent)
+	cmds.register("revisions", handlerRevisions)
+	cmds.register("podcasts", middlewareLoggedIn(handlerPodcasts))
+	cmds.register("download", middlewareLoggedIn(handlerDownload))
+	cmds.register("import", middlewareLoggedIn(handlerImport))
+	cmds.register("export", middlewareLoggedIn(handlerExport))
 	if len(os.Args) <= 1 {
 		log.Fatal("Commands and arguments are required")
 	}
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Rota-of-light/blogAgg/internal/config"
+	"github.com/Rota-of-light/blogAgg/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		name        string
+		fixture     string
+		contentType string
+		feedURL     string
+		format      string
+		title       string
+		link        string
+		items       []RSSItem
+	}{
+		{
+			name:        "RSS 2.0",
+			fixture:     "rss2.xml",
+			contentType: "application/rss+xml; charset=utf-8",
+			feedURL:     "https://example.com/feed.xml",
+			format:      "RSS",
+			title:       "Example Blog",
+			link:        "https://example.com/",
+			items: []RSSItem{
+				{
+					Title:          "First post",
+					Link:           "https://example.com/posts/first",
+					RawLink:        "/posts/first?utm_source=rss",
+					Description:    "<p>Hello <script>alert(1)</script>world</p>",
+					PubDate:        "Mon, 02 Jan 2006 15:04:05 -0700",
+					GUID:           "post-1",
+					ContentEncoded: "<p>The <em>full</em> text.</p>",
+					Creator:        "Jo Writer",
+					Categories:     []string{"go", "feeds"},
+					Enclosure:      &RSSEnclosure{URL: "https://example.com/media/first.mp3", Type: "audio/mpeg", Length: "1234"},
+				},
+				{
+					Title:       "Second post",
+					Link:        "https://example.com/posts/second",
+					RawLink:     "https://example.com/posts/second",
+					Description: "Plain text",
+				},
+			},
+		},
+		{
+			name:        "Atom",
+			fixture:     "atom.xml",
+			contentType: "application/atom+xml",
+			feedURL:     "https://example.org/blog/atom.xml",
+			format:      "Atom",
+			title:       "Atom Example",
+			link:        "https://example.org/blog/",
+			items: []RSSItem{
+				{
+					Title:          "Xhtml entry",
+					Link:           "https://example.org/blog/entries/1",
+					RawLink:        "entries/1",
+					Description:    "<p>Short <b>summary</b></p>",
+					PubDate:        "2006-01-02T15:04:05Z",
+					GUID:           "urn:uuid:1",
+					ContentEncoded: "<p>Long content</p>",
+					Author:         "Ann",
+					Categories:     []string{"news"},
+					Enclosure:      &RSSEnclosure{URL: "https://example.org/blog/audio/1.ogg", Type: "audio/ogg", Length: "99"},
+				},
+				{
+					Title:          "Updated only",
+					Link:           "https://other.example.org/posts/two.html",
+					RawLink:        "two.html",
+					Description:    "Only content",
+					PubDate:        "2006-01-03T00:00:00Z",
+					GUID:           "urn:uuid:2",
+					ContentEncoded: "Only content",
+				},
+			},
+		},
+		{
+			name:        "JSON Feed",
+			fixture:     "feed.json",
+			contentType: "application/feed+json",
+			feedURL:     "https://example.net/feed.json",
+			format:      "JSON Feed",
+			title:       "JSON Example",
+			link:        "https://example.net/",
+			items: []RSSItem{
+				{
+					Title:          "With HTML",
+					Link:           "https://example.net/1",
+					RawLink:        "https://example.net/1",
+					Description:    "Short",
+					PubDate:        "2006-01-02T15:04:05Z",
+					GUID:           "1",
+					ContentEncoded: "<p>Body</p>",
+					Author:         "Sam",
+					Categories:     []string{"a", "b"},
+					Enclosure:      &RSSEnclosure{URL: "https://example.net/1.mp3", Type: "audio/mpeg", Length: "42"},
+				},
+				{
+					Title:       "Text only",
+					Link:        "https://example.net/2",
+					RawLink:     "https://example.net/2",
+					Description: "Fish &amp; chips",
+					GUID:        "2",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := parseFeed(readFixture(t, tt.fixture), tt.contentType)
+			if err != nil {
+				t.Fatalf("parseFeed: %v", err)
+			}
+			resolveFeedLinks(feed, tt.feedURL)
+			if feed.Format != tt.format {
+				t.Errorf("Format = %q, want %q", feed.Format, tt.format)
+			}
+			if feed.Channel.Title != tt.title {
+				t.Errorf("Title = %q, want %q", feed.Channel.Title, tt.title)
+			}
+			if feed.Channel.Link != tt.link {
+				t.Errorf("Link = %q, want %q", feed.Channel.Link, tt.link)
+			}
+			if len(feed.Channel.Item) != len(tt.items) {
+				t.Fatalf("got %d items, want %d", len(feed.Channel.Item), len(tt.items))
+			}
+			for i, want := range tt.items {
+				got := feed.Channel.Item[i]
+				if got.Title != want.Title || got.Link != want.Link || got.RawLink != want.RawLink || got.GUID != want.GUID || got.PubDate != want.PubDate {
+					t.Errorf("item %d = %q %q %q %q %q, want %q %q %q %q %q", i, got.Title, got.Link, got.RawLink, got.GUID, got.PubDate, want.Title, want.Link, want.RawLink, want.GUID, want.PubDate)
+				}
+				if strings.TrimSpace(got.Description) != want.Description {
+					t.Errorf("item %d Description = %q, want %q", i, got.Description, want.Description)
+				}
+				if strings.TrimSpace(got.ContentEncoded) != want.ContentEncoded {
+					t.Errorf("item %d ContentEncoded = %q, want %q", i, got.ContentEncoded, want.ContentEncoded)
+				}
+				if itemAuthor(got) != itemAuthor(want) {
+					t.Errorf("item %d author = %q, want %q", i, itemAuthor(got), itemAuthor(want))
+				}
+				if strings.Join(got.Categories, ",") != strings.Join(want.Categories, ",") {
+					t.Errorf("item %d Categories = %q, want %q", i, got.Categories, want.Categories)
+				}
+				if (got.Enclosure == nil) != (want.Enclosure == nil) || (got.Enclosure != nil && *got.Enclosure != *want.Enclosure) {
+					t.Errorf("item %d Enclosure = %+v, want %+v", i, got.Enclosure, want.Enclosure)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchFeedCleansText(t *testing.T) {
+	const body = `<?xml version="1.0" encoding="utf-8"?>
+<rss version="2.0"><channel>
+<title>Fish &amp;amp; chips` + "\u009b" + `2J</title>
+<description>&lt;p&gt;About &lt;code&gt;a &amp;lt;b&amp;gt;&lt;/code&gt;&lt;/p&gt;</description>
+<language>en` + "\u009b" + `</language>
+<item>
+<title>Escaped &amp;lt;tag&amp;gt;</title>
+<link>/post` + "\u0085" + `</link>
+<description>&lt;pre&gt;if a &amp;lt; b {}&lt;/pre&gt;</description>
+<author>ann` + "\u009b" + `31m</author>
+<category>news` + "\u009b" + `</category>
+<comments>https://example.com/c` + "\u0090" + `</comments>
+<enclosure url="https://example.com/a.mp3` + "\u009b" + `" type="audio/mpeg` + "\u009b" + `" length="1` + "\u009b" + `"/>
+</item>
+</channel></rss>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+	feed, _, err := fetchFeed(context.Background(), server.URL+"/feed", cacheHeaders{}, fetchLimits{timeout: 5 * time.Second, maxBytes: 1 << 20})
+	if err != nil {
+		t.Fatalf("fetchFeed: %v", err)
+	}
+	channel := feed.Channel
+	if channel.Title != "Fish & chips2J" || channel.Language != "en" {
+		t.Errorf("channel = %q %q", channel.Title, channel.Language)
+	}
+	if channel.Description != "<p>About <code>a &lt;b&gt;</code></p>" {
+		t.Errorf("channel Description = %q", channel.Description)
+	}
+	item := channel.Item[0]
+	want := []string{"Escaped <tag>", server.URL + "/post", "<pre>if a &lt; b {}</pre>", "ann31m", "news", "https://example.com/c%C2%90", "https://example.com/a.mp3%C2%9B", "audio/mpeg", "1"}
+	got := []string{item.Title, item.Link, item.Description, item.Author, item.Categories[0], item.Comments, item.Enclosure.URL, item.Enclosure.Type, item.Enclosure.Length}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("field %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseFeedRejects(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		wantErr     string
+	}{
+		{
+			name:        "JSON that is not a JSON Feed",
+			body:        readFixture(t, "not_jsonfeed.json"),
+			contentType: "application/json",
+			wantErr:     "not a JSON Feed",
+		},
+		{
+			name:        "HTML page",
+			body:        []byte("<html><body>hi</body></html>"),
+			contentType: "text/html",
+			wantErr:     "root element: <html>",
+		},
+		{
+			name:        "unknown charset",
+			body:        []byte(`<?xml version="1.0" encoding="x-unknown"?><rss/>`),
+			contentType: "application/xml",
+			wantErr:     "Unsupported character encoding",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseFeed(tt.body, tt.contentType)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseFeed error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseFeedCharsets(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+	}{
+		{
+			name:        "declared in XML",
+			body:        append([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?><rss><channel><title>Caf`), 0xE9, '<', '/', 't', 'i', 't', 'l', 'e', '>', '<', '/', 'c', 'h', 'a', 'n', 'n', 'e', 'l', '>', '<', '/', 'r', 's', 's', '>'),
+			contentType: "application/rss+xml",
+			want:        "Café",
+		},
+		{
+			name:        "gb2312 from the header",
+			body:        append(append([]byte(`<rss><channel><title>`), 0xC4, 0xE3, 0xBA, 0xC3), []byte(`</title></channel></rss>`)...),
+			contentType: "text/xml; charset=gb2312",
+			want:        "你好",
+		},
+		{
+			name:        "big5",
+			body:        append(append([]byte(`<?xml version="1.0" encoding="big5"?><rss><channel><title>`), 0xA7, 0x41, 0xA6, 0x6E), []byte(`</title></channel></rss>`)...),
+			contentType: "application/xml",
+			want:        "你好",
+		},
+		{
+			name:        "euc-kr",
+			body:        append(append([]byte(`<?xml version="1.0" encoding="euc-kr"?><rss><channel><title>`), 0xBE, 0xC8, 0xB3, 0xE7), []byte(`</title></channel></rss>`)...),
+			contentType: "application/xml",
+			want:        "안녕",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := parseFeed(tt.body, tt.contentType)
+			if err != nil {
+				t.Fatalf("parseFeed: %v", err)
+			}
+			if feed.Channel.Title != tt.want {
+				t.Errorf("Title = %q, want %q", feed.Channel.Title, tt.want)
+			}
+		})
+	}
+}
+
+func TestAtomTextString(t *testing.T) {
+	tests := []struct {
+		name string
+		text AtomText
+		want string
+	}{
+		{"text is escaped", AtomText{Type: "text", Text: "if a <b> c"}, "if a &lt;b&gt; c"},
+		{"missing type means text", AtomText{Text: "Fish & chips"}, "Fish &amp; chips"},
+		{"html is kept", AtomText{Type: "html", Text: "<p>Hi</p>"}, "<p>Hi</p>"},
+		{"xhtml loses its div", AtomText{Type: "xhtml", Inner: `<div xmlns="http://www.w3.org/1999/xhtml"><p>Hi</p></div>`}, "<p>Hi</p>"},
+		{"empty xhtml div", AtomText{Type: "xhtml", Inner: `<div xmlns="http://www.w3.org/1999/xhtml"/>`}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.text.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlternateLink(t *testing.T) {
+	tests := []struct {
+		name  string
+		links []AtomLink
+		want  string
+		found bool
+	}{
+		{"alternate wins", []AtomLink{{Href: "self.xml", Rel: "self"}, {Href: "page", Rel: "alternate"}}, "page", true},
+		{"missing rel means alternate", []AtomLink{{Href: "a.mp3", Rel: "enclosure"}, {Href: "page"}}, "page", true},
+		{"unknown rel as a fallback", []AtomLink{{Href: "a.mp3", Rel: "enclosure"}, {Href: "page", Rel: "x-custom"}}, "page", true},
+		{"enclosure only", []AtomLink{{Href: "a.mp3", Rel: "enclosure"}}, "", false},
+		{"self only", []AtomLink{{Href: "feed.xml", Rel: "self"}}, "", false},
+		{"no links", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, found := alternateLink(tt.links)
+			if link.Href != tt.want || found != tt.found {
+				t.Errorf("alternateLink = %q, %v, want %q, %v", link.Href, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestItemGUIDs(t *testing.T) {
+	items := []RSSItem{
+		{GUID: "a", Link: "https://example.com/shared"},
+		{Title: "One", Link: "https://example.com/shared"},
+		{Title: "Two", Link: "https://example.com/shared"},
+		{Title: "Three", Link: "https://example.com/three"},
+		{Title: "Four", Description: "No link"},
+		{Title: "Five", Link: "a"},
+	}
+	want := []string{
+		"a",
+		hashKey("One\n"),
+		hashKey("Two\n"),
+		"https://example.com/three",
+		hashKey("Four\nNo link"),
+		hashKey("Five\n"),
+	}
+	got := itemGUIDs(items)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("key %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+// queryName matches sqlmock expectations against the sqlc query name.
+var queryName = sqlmock.QueryMatcherFunc(func(expected, actual string) error {
+	if !strings.HasPrefix(actual, "-- name: "+expected+" ") {
+		return sqlmock.ErrCancelled
+	}
+	return nil
+})
+
+func newMockState(t *testing.T, cfg config.Config) (*state, sqlmock.Sqlmock) {
+	t.Helper()
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(queryName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	return &state{db: database.New(conn), conn: conn, config: &cfg}, mock
+}
+
+var postColumns = strings.Split("id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, content_html, author, categories, comments_url, enclosure_url, enclosure_type, enclosure_length, downloaded_at, download_path, content, content_fetched_at, download_failed_at, download_error, content_lease_expires_at, download_lease_expires_at, content_attempts", ", ")
+
+func postRows(values map[string]driver.Value) *sqlmock.Rows {
+	row := make([]driver.Value, len(postColumns))
+	for i, column := range postColumns {
+		row[i] = values[column]
+		if id, ok := row[i].(uuid.UUID); ok {
+			row[i] = id.String()
+		}
+	}
+	row[1], row[2], row[12], row[25] = time.Now(), time.Now(), "{}", 0
+	return sqlmock.NewRows(postColumns).AddRow(row...)
+}
+
+func TestSavePost(t *testing.T) {
+	feedID := uuid.New()
+	postID := uuid.New()
+	published := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	firstSeen := time.Date(2006, 1, 5, 0, 0, 0, 0, time.UTC)
+	params := database.CreatePostParams{
+		ID:          uuid.New(),
+		Title:       sql.NullString{String: "Post", Valid: true},
+		Url:         "https://example.com/post",
+		PublishedAt: sql.NullTime{Time: firstSeen, Valid: true},
+		FeedID:      feedID,
+		Guid:        "https://example.com/post",
+		ContentHash: sql.NullString{String: "new", Valid: true},
+		Categories:  []string{},
+	}
+	rawLink := "/post?utm_source=rss"
+	legacyGUIDs, _ := pq.Array([]string{params.Url, hashKey(params.Url), rawLink, hashKey(rawLink)}).Value()
+	stored := func(guid string, hash driver.Value) *sqlmock.Rows {
+		return postRows(map[string]driver.Value{
+			"id":           postID,
+			"url":          rawLink,
+			"published_at": published,
+			"feed_id":      feedID,
+			"guid":         guid,
+			"content_hash": hash,
+		})
+	}
+	tests := []struct {
+		name        string
+		revisions   bool
+		dateMissing bool
+		expect      func(mock sqlmock.Sqlmock)
+		want        postOutcome
+	}{
+		{
+			name: "new post",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("GetPostByFeedGUID").WithArgs(feedID, params.Guid).WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("GetLegacyPost").WithArgs(feedID, legacyGUIDs).WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("CreatePost").WillReturnRows(postRows(map[string]driver.Value{"id": params.ID, "url": params.Url, "feed_id": feedID, "guid": params.Guid}))
+			},
+			want: postCreated,
+		},
+		{
+			name: "lost insert race",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("GetPostByFeedGUID").WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("GetLegacyPost").WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("CreatePost").WillReturnError(sql.ErrNoRows)
+			},
+			want: postUnchanged,
+		},
+		{
+			name: "unchanged post",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("GetPostByFeedGUID").WillReturnRows(stored(params.Guid, "new"))
+			},
+			want: postUnchanged,
+		},
+		{
+			name: "hash backfilled quietly",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("GetPostByFeedGUID").WillReturnRows(stored(params.Guid, nil))
+				mock.ExpectExec("UpdatePost").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: postUnchanged,
+		},
+		{
+			name:        "edited post keeps its date and a revision",
+			revisions:   true,
+			dateMissing: true,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("GetPostByFeedGUID").WillReturnRows(stored(params.Guid, "old"))
+				mock.ExpectExec("CreatePostRevision").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), postID, sqlmock.AnyArg(), rawLink, sqlmock.AnyArg(), published, "old").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UpdatePost").WithArgs(
+					params.Title, params.Url, params.Description, published, "new", sqlmock.AnyArg(),
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), postID,
+				).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: postUpdated,
+		},
+		{
+			name: "edited post without revisions",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("GetPostByFeedGUID").WillReturnRows(stored(params.Guid, "old"))
+				mock.ExpectExec("UpdatePost").WithArgs(
+					params.Title, params.Url, params.Description, firstSeen, "new", sqlmock.AnyArg(),
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), postID,
+				).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: postUpdated,
+		},
+		{
+			name: "legacy post adopted",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("GetPostByFeedGUID").WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("GetLegacyPost").WithArgs(feedID, legacyGUIDs).WillReturnRows(stored(hashKey(rawLink), "new"))
+				mock.ExpectExec("UpdatePostGUID").WithArgs(params.Guid, postID).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: postUnchanged,
+		},
+		{
+			name: "legacy post adopted and updated",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("GetPostByFeedGUID").WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("GetLegacyPost").WillReturnRows(stored(rawLink, "old"))
+				mock.ExpectExec("UpdatePostGUID").WithArgs(params.Guid, postID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UpdatePost").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: postUpdated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockState(t, config.Config{KeepPostRevisions: tt.revisions})
+			tt.expect(mock)
+			got, err := savePost(context.Background(), s, params, rawLink, tt.dateMissing)
+			if err != nil {
+				t.Fatalf("savePost: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("outcome = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
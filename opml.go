@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Rota-of-light/blogAgg/internal/database"
+	"github.com/google/uuid"
+)
+
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    OPMLHead `xml:"head"`
+	Body    OPMLBody `xml:"body"`
+}
+
+type OPMLHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type OPMLBody struct {
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+type OPMLOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+type opmlSubscription struct {
+	Name   string
+	URL    string
+	Folder string
+}
+
+// flattenOutlines joins the names of enclosing folders with "/".
+func flattenOutlines(outlines []OPMLOutline, folder string) []opmlSubscription {
+	var subs []opmlSubscription
+	for _, outline := range outlines {
+		name := outline.Title
+		if name == "" {
+			name = outline.Text
+		}
+		if outline.XMLURL != "" {
+			if name == "" {
+				name = outline.XMLURL
+			}
+			subs = append(subs, opmlSubscription{
+				Name:   name,
+				URL:    strings.TrimSpace(outline.XMLURL),
+				Folder: folder,
+			})
+		}
+		if len(outline.Outlines) > 0 {
+			child := name
+			if folder != "" {
+				child = folder + "/" + name
+			}
+			subs = append(subs, flattenOutlines(outline.Outlines, child)...)
+		}
+	}
+	return subs
+}
+
+func handlerImport(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("Either an OPML file is needed or too many were given.")
+	}
+	data, err := os.ReadFile(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("Error reading OPML file: %w", err)
+	}
+	var doc OPML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("Error parsing OPML file: %w", err)
+	}
+	ctx := context.Background()
+	follows, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("Error with getting feeds that were followed: %w", err)
+	}
+	following := map[uuid.UUID]sql.NullString{}
+	for _, follow := range follows {
+		following[follow.FeedID] = follow.Folder
+	}
+	created, followed, moved := 0, 0, 0
+	for _, sub := range flattenOutlines(doc.Body.Outlines, "") {
+		feed, err := s.db.GetFeedsByURLS(ctx, sub.URL)
+		if errors.Is(err, sql.ErrNoRows) {
+			feed, err = s.db.CreateFeed(ctx, database.CreateFeedParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				Name:      sub.Name,
+				Url:       sub.URL,
+				UserID:    user.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("Error creating feed %v: %w", sub.URL, err)
+			}
+			created++
+		} else if err != nil {
+			return fmt.Errorf("Error getting feed via URL from table: %w", err)
+		}
+		if folder, ok := following[feed.ID]; ok {
+			if sub.Folder == "" || folder.String == sub.Folder {
+				continue
+			}
+			err = s.db.SetFeedFollowFolder(ctx, database.SetFeedFollowFolderParams{
+				Folder:    optionalString(sub.Folder),
+				UpdatedAt: time.Now(),
+				UserID:    user.ID,
+				FeedID:    feed.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("Error moving feed %v to folder %v: %w", sub.URL, sub.Folder, err)
+			}
+			following[feed.ID] = optionalString(sub.Folder)
+			moved++
+			continue
+		}
+		_, err = s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+			Folder:    optionalString(sub.Folder),
+		})
+		if err != nil {
+			return fmt.Errorf("Error following feed %v: %w", sub.URL, err)
+		}
+		following[feed.ID] = optionalString(sub.Folder)
+		followed++
+	}
+	fmt.Printf("Imported %d new feeds, followed %d feeds and moved %d to other folders.\n", created, followed, moved)
+	return nil
+}
+
+type opmlFolder struct {
+	feeds      []OPMLOutline
+	subfolders map[string]*opmlFolder
+}
+
+func (f *opmlFolder) outlines() []OPMLOutline {
+	outlines := f.feeds
+	names := make([]string, 0, len(f.subfolders))
+	for name := range f.subfolders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		outlines = append(outlines, OPMLOutline{
+			Text:     name,
+			Title:    name,
+			Outlines: f.subfolders[name].outlines(),
+		})
+	}
+	return outlines
+}
+
+func buildOPML(user database.User, follows []database.GetFeedFollowsForUserRow) OPML {
+	doc := OPML{
+		Version: "2.0",
+		Head: OPMLHead{
+			Title:       fmt.Sprintf("%v's subscriptions", user.Name),
+			DateCreated: time.Now().Format(time.RFC1123Z),
+		},
+	}
+	root := &opmlFolder{subfolders: map[string]*opmlFolder{}}
+	for _, follow := range follows {
+		folder := root
+		for _, name := range strings.Split(follow.Folder.String, "/") {
+			if name == "" {
+				continue
+			}
+			child, ok := folder.subfolders[name]
+			if !ok {
+				child = &opmlFolder{subfolders: map[string]*opmlFolder{}}
+				folder.subfolders[name] = child
+			}
+			folder = child
+		}
+		folder.feeds = append(folder.feeds, OPMLOutline{
+			Text:    follow.FeedName,
+			Title:   follow.FeedName,
+			Type:    "rss",
+			XMLURL:  follow.FeedUrl,
+			HTMLURL: follow.FeedSiteLink.String,
+		})
+	}
+	doc.Body.Outlines = root.outlines()
+	return doc
+}
+
+func handlerExport(s *state, cmd command, user database.User) error {
+	if len(cmd.args) > 1 {
+		return fmt.Errorf("Error: Command accepts at most one argument, the file to write.")
+	}
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("Error with getting feeds that were followed: %w", err)
+	}
+	data, err := xml.MarshalIndent(buildOPML(user, follows), "", "  ")
+	if err != nil {
+		return fmt.Errorf("Error building OPML: %w", err)
+	}
+	output := xml.Header + string(data) + "\n"
+	if len(cmd.args) == 0 {
+		if _, err := io.WriteString(os.Stdout, output); err != nil {
+			return fmt.Errorf("Error writing OPML: %w", err)
+		}
+		return nil
+	}
+	file, err := os.Create(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("Error creating export file: %w", err)
+	}
+	if _, err := io.WriteString(file, output); err != nil {
+		file.Close()
+		return fmt.Errorf("Error writing OPML: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("Error writing OPML: %w", err)
+	}
+	fmt.Printf("Exported %d feeds to %v\n", len(follows), cmd.args[0])
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/Rota-of-light/blogAgg/internal/database"
+)
+
+func TestFlattenOutlines(t *testing.T) {
+	const doc = `<?xml version="1.0"?>
+<opml version="1.0">
+  <head><title>Subscriptions</title></head>
+  <body>
+    <outline text="Top" type="rss" xmlUrl=" https://example.com/top.xml "/>
+    <outline text="Tech">
+      <outline title="Go Blog" text="go" type="rss" xmlUrl="https://go.dev/blog/feed.atom"/>
+      <outline text="Databases">
+        <outline text="" xmlUrl="https://example.org/db.xml"/>
+      </outline>
+    </outline>
+    <outline text="Empty folder"/>
+    <outline text="Feed with children" xmlUrl="https://example.net/feed">
+      <outline text="Child" xmlUrl="https://example.net/child"/>
+    </outline>
+  </body>
+</opml>`
+	var parsed OPML
+	if err := xml.Unmarshal([]byte(doc), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	want := []opmlSubscription{
+		{Name: "Top", URL: "https://example.com/top.xml"},
+		{Name: "Go Blog", URL: "https://go.dev/blog/feed.atom", Folder: "Tech"},
+		{Name: "https://example.org/db.xml", URL: "https://example.org/db.xml", Folder: "Tech/Databases"},
+		{Name: "Feed with children", URL: "https://example.net/feed"},
+		{Name: "Child", URL: "https://example.net/child", Folder: "Feed with children"},
+	}
+	if got := flattenOutlines(parsed.Body.Outlines, ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("flattenOutlines = %+v, want %+v", got, want)
+	}
+}
+
+func TestBuildOPML(t *testing.T) {
+	follow := func(name, url, folder, site string) database.GetFeedFollowsForUserRow {
+		return database.GetFeedFollowsForUserRow{
+			FeedName:     name,
+			FeedUrl:      url,
+			Folder:       optionalString(folder),
+			FeedSiteLink: sql.NullString{String: site, Valid: site != ""},
+		}
+	}
+	follows := []database.GetFeedFollowsForUserRow{
+		follow("Top", "https://example.com/top.xml", "", "https://example.com/"),
+		follow("Go Blog", "https://go.dev/blog/feed.atom", "Tech", ""),
+		follow("DB", "https://example.org/db.xml", "Tech/Databases", ""),
+		follow("Art", "https://example.net/art.xml", "Arts", ""),
+	}
+	doc := buildOPML(database.User{Name: "kim"}, follows)
+	if doc.Version != "2.0" || doc.Head.Title != "kim's subscriptions" {
+		t.Errorf("head = %q %+v", doc.Version, doc.Head)
+	}
+	outlines := doc.Body.Outlines
+	if len(outlines) != 3 || outlines[0].XMLURL != "https://example.com/top.xml" || outlines[0].HTMLURL != "https://example.com/" || outlines[0].Type != "rss" {
+		t.Fatalf("top level = %+v", outlines)
+	}
+	if outlines[1].Text != "Arts" || outlines[2].Text != "Tech" {
+		t.Errorf("folders = %q, %q, want Arts then Tech", outlines[1].Text, outlines[2].Text)
+	}
+	tech := outlines[2].Outlines
+	if len(tech) != 2 || tech[0].Title != "Go Blog" || tech[1].Text != "Databases" || tech[1].Outlines[0].XMLURL != "https://example.org/db.xml" {
+		t.Errorf("Tech folder = %+v", tech)
+	}
+
+	data, err := xml.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed OPML
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, sub := range flattenOutlines(parsed.Body.Outlines, "") {
+		got[sub.URL] = sub.Folder
+	}
+	for _, follow := range follows {
+		if folder, ok := got[follow.FeedUrl]; !ok || folder != follow.Folder.String {
+			t.Errorf("%v came back in folder %q (found %v), want %q", follow.FeedUrl, folder, ok, follow.Folder.String)
+		}
+	}
+}
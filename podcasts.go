@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rota-of-light/blogAgg/internal/database"
+	"github.com/google/uuid"
+)
+
+var errDownloadTooLarge = errors.New("Enclosure is larger than the configured download limit")
+
+const downloadRetryDelay = 24 * time.Hour
+
+// downloadLease is also how long an episode on a paused host waits.
+const downloadLease = time.Hour
+
+// downloadFileName adds part of the post ID so episodes sharing a title do not collide.
+func downloadFileName(post database.Post) string {
+	var name strings.Builder
+	for _, r := range strings.ToLower(post.Title.String) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			name.WriteRune(r)
+		case name.Len() > 0 && !strings.HasSuffix(name.String(), "-"):
+			name.WriteRune('-')
+		}
+		if name.Len() >= 80 {
+			break
+		}
+	}
+	base := strings.Trim(name.String(), "-")
+	if base == "" {
+		base = "episode"
+	}
+	ext := ""
+	if parsed, err := url.Parse(post.EnclosureUrl.String); err == nil {
+		ext = path.Ext(parsed.Path)
+	}
+	return fmt.Sprintf("%s-%s%s", base, post.ID.String()[:8], ext)
+}
+
+// stallReader gives up once no data has arrived for timeout.
+type stallReader struct {
+	reader  io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (r *stallReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	return n, err
+}
+
+// contentRange returns -1 for values it cannot read.
+func contentRange(header string) (start, total int64) {
+	start, total = -1, -1
+	spec, found := strings.CutPrefix(strings.TrimSpace(header), "bytes ")
+	if !found {
+		return start, total
+	}
+	span, size, _ := strings.Cut(spec, "/")
+	if first, _, ok := strings.Cut(span, "-"); ok {
+		if parsed, err := strconv.ParseInt(first, 10, 64); err == nil {
+			start = parsed
+		}
+	}
+	if parsed, err := strconv.ParseInt(size, 10, 64); err == nil {
+		total = parsed
+	}
+	return start, total
+}
+
+// downloadEnclosure resumes a .part file with a Range request.
+func downloadEnclosure(ctx context.Context, post database.Post, dir string, maxBytes int64, limits fetchLimits) (string, error) {
+	if post.EnclosureLength.Valid && post.EnclosureLength.Int64 > maxBytes {
+		return "", errDownloadTooLarge
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, downloadFileName(post))
+	partial := dest + ".part"
+	var offset int64
+	if info, err := os.Stat(partial); err == nil {
+		offset = info.Size()
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	if err := limits.hosts.wait(ctx, post.EnclosureUrl.String); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, post.EnclosureUrl.String, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "gator")
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: limits.timeout}).DialContext,
+			TLSHandshakeTimeout:   limits.timeout,
+			ResponseHeaderTimeout: limits.timeout,
+		},
+	}
+	defer client.CloseIdleConnections()
+	stalled := time.AfterFunc(limits.timeout, func() {
+		cancel(&FetchTimeoutError{URL: post.EnclosureUrl.String, Timeout: limits.timeout})
+	})
+	defer stalled.Stop()
+	res, err := client.Do(req)
+	if err != nil {
+		return "", timeoutError(ctx, post.EnclosureUrl.String, limits, err)
+	}
+	defer res.Body.Close()
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case res.StatusCode == http.StatusPartialContent:
+		start, _ := contentRange(res.Header.Get("Content-Range"))
+		if start == 0 {
+			offset = 0
+			flags |= os.O_TRUNC
+		} else if start == offset {
+			flags |= os.O_APPEND
+		} else {
+			os.Remove(partial)
+			return "", fmt.Errorf("Server resumed %s at byte %d instead of %d", post.EnclosureUrl.String, start, offset)
+		}
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		if _, total := contentRange(res.Header.Get("Content-Range")); total == offset {
+			return dest, os.Rename(partial, dest)
+		}
+		os.Remove(partial)
+		return "", fmt.Errorf("Server rejected resuming %s at byte %d", post.EnclosureUrl.String, offset)
+	case res.StatusCode >= 200 && res.StatusCode <= 299:
+		offset = 0
+		flags |= os.O_TRUNC
+	default:
+		return "", statusError(res, post.EnclosureUrl.String, limits)
+	}
+	if res.ContentLength > 0 && offset+res.ContentLength > maxBytes {
+		return "", errDownloadTooLarge
+	}
+	file, err := os.OpenFile(partial, flags, 0644)
+	if err != nil {
+		return "", err
+	}
+	body := &stallReader{reader: res.Body, timer: stalled, timeout: limits.timeout}
+	written, err := io.Copy(file, io.LimitReader(body, maxBytes-offset+1))
+	closeErr := file.Close()
+	if err != nil {
+		return "", timeoutError(ctx, post.EnclosureUrl.String, limits, err)
+	}
+	if closeErr != nil {
+		return "", closeErr
+	}
+	if offset+written > maxBytes {
+		os.Remove(partial)
+		return "", errDownloadTooLarge
+	}
+	return dest, os.Rename(partial, dest)
+}
+
+func downloadPending(ctx context.Context, s *state, userID uuid.NullUUID, limit int32) (int, error) {
+	dir, err := s.config.DownloadDirectory()
+	if err != nil {
+		return 0, err
+	}
+	downloaded := 0
+	for attempt := int32(0); attempt < limit && ctx.Err() == nil; attempt++ {
+		now := time.Now()
+		post, err := s.db.ClaimPendingDownload(ctx, database.ClaimPendingDownloadParams{
+			LeaseExpiresAt: sql.NullTime{
+				Time:  now.Add(downloadLease),
+				Valid: true,
+			},
+			RetryBefore: sql.NullTime{
+				Time:  now.Add(-downloadRetryDelay),
+				Valid: true,
+			},
+			Now: sql.NullTime{
+				Time:  now,
+				Valid: true,
+			},
+			UserID: userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return downloaded, err
+		}
+		fmt.Printf("Downloading %v\n", post.Title.String)
+		dest, err := downloadEnclosure(ctx, post, dir, s.config.DownloadLimit(), newFetchLimits(s))
+		if err != nil && ctx.Err() != nil {
+			break
+		}
+		var paused *HostPausedError
+		if errors.As(err, &paused) {
+			log.Printf("Skipping %v: %v", post.EnclosureUrl.String, paused)
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to download %v: %v", post.EnclosureUrl.String, err)
+			err = s.db.MarkDownloadFailed(context.WithoutCancel(ctx), database.MarkDownloadFailedParams{
+				DownloadFailedAt: sql.NullTime{
+					Time:  time.Now(),
+					Valid: true,
+				},
+				DownloadError: sql.NullString{
+					String: err.Error(),
+					Valid:  true,
+				},
+				ID: post.ID,
+			})
+			if err != nil {
+				return downloaded, err
+			}
+			continue
+		}
+		err = s.db.MarkPostDownloaded(context.WithoutCancel(ctx), database.MarkPostDownloadedParams{
+			DownloadedAt: sql.NullTime{
+				Time:  time.Now(),
+				Valid: true,
+			},
+			DownloadPath: sql.NullString{
+				String: dest,
+				Valid:  true,
+			},
+			ID: post.ID,
+		})
+		if err != nil {
+			return downloaded, err
+		}
+		downloaded++
+	}
+	return downloaded, nil
+}
+
+func parseLimitArg(args []string, fallback int32) (int32, error) {
+	if len(args) >= 2 {
+		return 0, fmt.Errorf("Error: Command accepts at most one argument, which must be a postive whole number.")
+	}
+	if len(args) == 0 {
+		return fallback, nil
+	}
+	parsedLimit, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("Error: Limit must be a whole number.")
+	}
+	if parsedLimit <= 0 {
+		return 0, fmt.Errorf("Error: Limit must be a positive whole number.")
+	}
+	return int32(parsedLimit), nil
+}
+
+func handlerPodcasts(s *state, cmd command, user database.User) error {
+	limit, err := parseLimitArg(cmd.args, 10)
+	if err != nil {
+		return err
+	}
+	posts, err := s.db.GetMediaPostsByUser(context.Background(), database.GetMediaPostsByUserParams{
+		UserID: user.ID,
+		Limit:  limit,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve podcast episodes for user %v: %w", user.Name, err)
+	}
+	if len(posts) == 0 {
+		fmt.Printf("No podcast episodes available.\n")
+		return nil
+	}
+	for _, post := range posts {
+		status := "not downloaded"
+		if post.DownloadedAt.Valid {
+			status = "downloaded to " + post.DownloadPath.String
+		} else if post.DownloadFailedAt.Valid {
+			status = "download failed: " + post.DownloadError.String
+		}
+		fmt.Printf("	-%v (%v)\n", post.Title.String, post.EnclosureType.String)
+		fmt.Printf("	 %v\n", post.EnclosureUrl.String)
+		fmt.Printf("	 %v\n\n", status)
+	}
+	return nil
+}
+
+func handlerDownload(s *state, cmd command, user database.User) error {
+	limit, err := parseLimitArg(cmd.args, 5)
+	if err != nil {
+		return err
+	}
+	downloaded, err := downloadPending(context.Background(), s, uuid.NullUUID{UUID: user.ID, Valid: true}, limit)
+	if err != nil {
+		return fmt.Errorf("Error downloading episodes: %w", err)
+	}
+	fmt.Printf("Downloaded %d episodes.\n", downloaded)
+	return nil
+}
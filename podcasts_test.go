@@ -0,0 +1,61 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/Rota-of-light/blogAgg/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestContentRange(t *testing.T) {
+	tests := []struct {
+		header string
+		start  int64
+		total  int64
+	}{
+		{"bytes 100-199/1000", 100, 1000},
+		{"bytes 0-999/*", 0, -1},
+		{"bytes */1000", -1, 1000},
+		{" bytes 5-9/10 ", 5, 10},
+		{"items 0-9/10", -1, -1},
+		{"", -1, -1},
+		{"bytes x-9/y", -1, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			start, total := contentRange(tt.header)
+			if start != tt.start || total != tt.total {
+				t.Errorf("contentRange(%q) = %d, %d, want %d, %d", tt.header, start, total, tt.start, tt.total)
+			}
+		})
+	}
+}
+
+func TestDownloadFileName(t *testing.T) {
+	id := uuid.MustParse("0123abcd-0000-0000-0000-000000000000")
+	tests := []struct {
+		name      string
+		title     string
+		enclosure string
+		want      string
+	}{
+		{"title and extension", "Episode 12: The End!", "https://example.com/media/ep12.mp3?token=1", "episode-12-the-end-0123abcd.mp3"},
+		{"no usable title", "¿¡?", "https://example.com/audio", "episode-0123abcd"},
+		{"path separators are dropped", "../../etc/passwd", "https://example.com/a.ogg", "etc-passwd-0123abcd.ogg"},
+		{"long titles are cut", strings.Repeat("word ", 40), "https://example.com/a.m4a", strings.TrimSuffix(strings.Repeat("word-", 16), "-") + "-0123abcd.m4a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := database.Post{
+				ID:           id,
+				Title:        sql.NullString{String: tt.title, Valid: true},
+				EnclosureUrl: sql.NullString{String: tt.enclosure, Valid: true},
+			}
+			if got := downloadFileName(post); got != tt.want {
+				t.Errorf("downloadFileName = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
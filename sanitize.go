@@ -0,0 +1,449 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// droppedTags are removed with their contents.
+var droppedTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"textarea": true,
+	"title":    true,
+	"xmp":      true,
+	"iframe":   true,
+	"noembed":  true,
+	"noframes": true,
+	"noscript": true,
+	"head":     true,
+	"object":   true,
+	"embed":    true,
+	"template": true,
+	"select":   true,
+	"svg":      true,
+	"math":     true,
+	"form":     true,
+}
+
+var voidTags = map[string]bool{
+	"br":     true,
+	"hr":     true,
+	"img":    true,
+	"wbr":    true,
+	"input":  true,
+	"meta":   true,
+	"link":   true,
+	"source": true,
+	"col":    true,
+	"area":   true,
+	"base":   true,
+	"embed":  true,
+	"param":  true,
+	"track":  true,
+}
+
+func parseHTMLFragment(s string) []*html.Node {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		return nil
+	}
+	return nodes
+}
+
+var allowedTags = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"b":          nil,
+	"blockquote": nil,
+	"br":         nil,
+	"caption":    nil,
+	"cite":       nil,
+	"code":       nil,
+	"dd":         nil,
+	"del":        nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"ins":        nil,
+	"kbd":        nil,
+	"li":         nil,
+	"mark":       nil,
+	"ol":         {"start"},
+	"p":          nil,
+	"pre":        nil,
+	"q":          nil,
+	"s":          nil,
+	"small":      nil,
+	"strong":     nil,
+	"sub":        nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"colspan", "rowspan"},
+	"tfoot":      nil,
+	"th":         {"colspan", "rowspan"},
+	"thead":      nil,
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+}
+
+// safeLink allows relative links and schemes that cannot run code.
+func safeLink(value string) (string, bool) {
+	value = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7F {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto":
+		return value, true
+	}
+	return "", false
+}
+
+// sanitizeHTML unwraps tags outside the allowlist and drops droppedTags entirely.
+func sanitizeHTML(s string) string {
+	var out strings.Builder
+	for _, node := range parseHTMLFragment(s) {
+		writeSanitized(&out, node)
+	}
+	return strings.TrimSpace(out.String())
+}
+
+func writeSanitized(out *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		out.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	if droppedTags[n.Data] {
+		return
+	}
+	attrs, ok := allowedTags[n.Data]
+	if ok {
+		out.WriteString("<" + n.Data)
+		for _, attr := range n.Attr {
+			if attr.Namespace != "" || !containsString(attrs, attr.Key) {
+				continue
+			}
+			value := attr.Val
+			if attr.Key == "href" || attr.Key == "src" {
+				var safe bool
+				if value, safe = safeLink(value); !safe {
+					continue
+				}
+			}
+			fmt.Fprintf(out, ` %s="%s"`, attr.Key, html.EscapeString(value))
+		}
+		out.WriteString(">")
+		if voidTags[n.Data] {
+			return
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		writeSanitized(out, child)
+	}
+	if ok {
+		out.WriteString("</" + n.Data + ">")
+	}
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// stripControls keeps newlines and turns tabs into spaces.
+func stripControls(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n':
+			return r
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, s)
+}
+
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "dd": true, "div": true,
+	"dl": true, "dt": true, "figcaption": true, "figure": true, "footer": true, "h1": true,
+	"h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true, "main": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "section": true, "table": true, "ul": true,
+}
+
+type textRenderer struct {
+	width    int
+	oneLine  bool
+	lines    []string
+	current  strings.Builder
+	blank    bool
+	bullet   string
+	lists    []int
+	quotes   int
+	pre      int
+	links    []string
+	linkHref []string
+}
+
+func (r *textRenderer) prefix() string {
+	if r.oneLine {
+		return ""
+	}
+	return strings.Repeat("> ", r.quotes) + strings.Repeat("  ", max(len(r.lists)-1, 0))
+}
+
+func (r *textRenderer) flush(blank bool) {
+	text := r.current.String()
+	r.current.Reset()
+	var body []string
+	if r.pre > 0 {
+		body = strings.Split(strings.Trim(text, "\n"), "\n")
+		if strings.TrimSpace(text) == "" {
+			body = nil
+		}
+	} else if words := strings.Fields(text); len(words) > 0 {
+		body = wrapWords(words, r.width-utf8.RuneCountInString(r.prefix()+r.bullet))
+	}
+	if len(body) > 0 {
+		if r.blank && len(r.lines) > 0 {
+			r.lines = append(r.lines, "")
+		}
+		indent := r.prefix()
+		hanging := strings.Repeat(" ", utf8.RuneCountInString(r.bullet))
+		for i, line := range body {
+			lead := hanging
+			if i == 0 {
+				lead = r.bullet
+			}
+			r.lines = append(r.lines, strings.TrimRight(indent+lead+line, " "))
+		}
+		r.bullet = ""
+		r.blank = false
+	}
+	if blank {
+		r.blank = true
+	}
+}
+
+func attrValue(n *html.Node, name string) string {
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func (r *textRenderer) startTag(n *html.Node) {
+	switch {
+	case n.Data == "br":
+		if r.pre > 0 {
+			r.current.WriteString("\n")
+		} else {
+			r.flush(false)
+		}
+	case n.Data == "hr":
+		r.flush(true)
+		r.lines = append(r.lines, strings.Repeat("-", min(r.width, 20)))
+	case n.Data == "li":
+		r.flush(false)
+		if len(r.lists) == 0 {
+			r.bullet = "- "
+		} else if r.lists[len(r.lists)-1] < 0 {
+			r.bullet = "- "
+		} else {
+			r.lists[len(r.lists)-1]++
+			r.bullet = strconv.Itoa(r.lists[len(r.lists)-1]) + ". "
+		}
+	case n.Data == "ul" || n.Data == "ol":
+		r.flush(len(r.lists) == 0)
+		counter := -1
+		if n.Data == "ol" {
+			counter = 0
+			if start, err := strconv.Atoi(strings.TrimSpace(attrValue(n, "start"))); err == nil {
+				counter = start - 1
+			}
+		}
+		r.lists = append(r.lists, counter)
+	case n.Data == "tr":
+		r.flush(false)
+	case n.Data == "td" || n.Data == "th":
+		r.current.WriteString(" ")
+	case n.Data == "a":
+		r.linkHref = append(r.linkHref, stripControls(strings.TrimSpace(attrValue(n, "href"))))
+	case n.Data == "img":
+		alt := strings.TrimSpace(stripControls(attrValue(n, "alt")))
+		if alt != "" {
+			r.current.WriteString(" [image: " + alt + "] ")
+		} else {
+			r.current.WriteString(" [image] ")
+		}
+	case blockTags[n.Data]:
+		r.flush(true)
+		if n.Data == "blockquote" {
+			r.quotes++
+		}
+		if n.Data == "pre" {
+			r.pre++
+		}
+	}
+}
+
+func (r *textRenderer) endTag(name string) {
+	switch {
+	case name == "a":
+		if len(r.linkHref) == 0 {
+			return
+		}
+		href := r.linkHref[len(r.linkHref)-1]
+		r.linkHref = r.linkHref[:len(r.linkHref)-1]
+		if r.oneLine || href == "" || strings.HasPrefix(href, "#") {
+			return
+		}
+		number := 0
+		for i, link := range r.links {
+			if link == href {
+				number = i + 1
+			}
+		}
+		if number == 0 {
+			r.links = append(r.links, href)
+			number = len(r.links)
+		}
+		fmt.Fprintf(&r.current, " [%d]", number)
+	case name == "li" || name == "tr":
+		r.flush(false)
+	case name == "ul" || name == "ol":
+		r.flush(false)
+		if len(r.lists) > 0 {
+			r.lists = r.lists[:len(r.lists)-1]
+		}
+		if len(r.lists) == 0 {
+			r.blank = true
+		}
+	case blockTags[name]:
+		r.flush(true)
+		if name == "blockquote" && r.quotes > 0 {
+			r.quotes--
+		}
+		if name == "pre" && r.pre > 0 {
+			r.pre--
+		}
+	}
+}
+
+func (r *textRenderer) render(s string) {
+	for _, node := range parseHTMLFragment(s) {
+		r.renderNode(node)
+	}
+	r.flush(false)
+}
+
+func (r *textRenderer) renderNode(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.current.WriteString(stripControls(n.Data))
+	case html.ElementNode:
+		if droppedTags[n.Data] {
+			return
+		}
+		r.startTag(n)
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			r.renderNode(child)
+		}
+		if !voidTags[n.Data] {
+			r.endTag(n.Data)
+		}
+	}
+}
+
+// renderHTMLText wraps text to width and lists link targets as footnotes.
+func renderHTMLText(s string, width int) []string {
+	r := &textRenderer{width: width}
+	r.render(s)
+	if len(r.links) > 0 {
+		r.lines = append(r.lines, "")
+		for i, link := range r.links {
+			r.lines = append(r.lines, fmt.Sprintf("[%d] %s", i+1, link))
+		}
+	}
+	return r.lines
+}
+
+func htmlToText(s string) string {
+	r := &textRenderer{oneLine: true}
+	r.render(s)
+	return strings.Join(strings.Fields(strings.Join(r.lines, " ")), " ")
+}
+
+func wrapWords(words []string, width int) []string {
+	if width <= 0 {
+		return []string{strings.Join(words, " ")}
+	}
+	var lines []string
+	var line strings.Builder
+	length := 0
+	for _, word := range words {
+		wordLength := utf8.RuneCountInString(word)
+		if length > 0 && length+1+wordLength > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			length = 0
+		}
+		if length > 0 {
+			line.WriteString(" ")
+			length++
+		}
+		line.WriteString(word)
+		length += wordLength
+	}
+	if length > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+func terminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns >= 40 {
+		return columns
+	}
+	return 80
+}
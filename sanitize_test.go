@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text is escaped", `Fish & chips < 3`, `Fish &amp; chips &lt; 3`},
+		{"allowed markup is kept", `<p>Some <strong>bold</strong> and <em>italic</em></p>`, `<p>Some <strong>bold</strong> and <em>italic</em></p>`},
+		{"scripts go with their content", `<p>a<script>alert(1)</script>b</p>`, `<p>ab</p>`},
+		{"styles and iframes are dropped", `<style>p{}</style><iframe src="x">frame</iframe>text`, `text`},
+		{"unknown tags are unwrapped", `<div><span class="x">kept</span></div>`, `kept`},
+		{"event handlers are removed", `<a href="https://example.com" onclick="evil()">link</a>`, `<a href="https://example.com">link</a>`},
+		{"javascript links are removed", `<a href="javascript:alert(1)">link</a>`, `<a>link</a>`},
+		{"obfuscated javascript links are removed", `<a href="jav&#x09;ascript:alert(1)">link</a>`, `<a>link</a>`},
+		{"data images are removed", `<img src="data:image/png;base64,AAAA" alt="x">`, `<img alt="x">`},
+		{"relative links are kept", `<img src="/a.png" alt="a &amp; b">`, `<img src="/a.png" alt="a &amp; b">`},
+		{"unclosed tags are closed", `<p>one<p>two <b>bold`, `<p>one</p><p>two <b>bold</b></p>`},
+		{"list items close each other", `<ul><li>a<li>b</ul>`, `<ul><li>a</li><li>b</li></ul>`},
+		{"stray end tags are ignored", `a</p></div>b`, `a<p></p>b`},
+		{"comments are removed", `a<!-- secret -->b`, `ab`},
+		{"attributes are escaped", `<a href="https://example.com/?a=1&amp;b=&quot;2&quot;">x</a>`, `<a href="https://example.com/?a=1&amp;b=&#34;2&#34;">x</a>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeHTML(tt.in); got != tt.want {
+				t.Errorf("sanitizeHTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHTMLTextStripsControls(t *testing.T) {
+	tests := []string{
+		"<p>red \x1b[31mtext</p>",
+		"title \x1b]0;owned\x07 here",
+		`<a href="https://example.com/` + "\x1b[2J" + `">link</a>`,
+		`<img alt="` + "\x1b[5m" + `blink">`,
+	}
+	for _, in := range tests {
+		for _, line := range renderHTMLText(in, 80) {
+			if strings.ContainsAny(line, "\x1b\x07") {
+				t.Errorf("renderHTMLText(%q) kept a control character in %q", in, line)
+			}
+		}
+		if got := htmlToText(in); strings.ContainsAny(got, "\x1b\x07") {
+			t.Errorf("htmlToText(%q) = %q, kept a control character", in, got)
+		}
+	}
+}
+
+func TestRenderHTMLText(t *testing.T) {
+	in := `<p>First paragraph.</p><blockquote><p>Quoted</p></blockquote><ol start="3"><li>three<li>four</ol><p><a href="https://example.com">a link</a></p>`
+	want := []string{
+		"First paragraph.",
+		"",
+		"> Quoted",
+		"",
+		"3. three",
+		"4. four",
+		"",
+		"a link [1]",
+		"",
+		"[1] https://example.com",
+	}
+	got := renderHTMLText(in, 80)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("renderHTMLText =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := htmlToText(in); got != "First paragraph. Quoted 3. three 4. four a link" {
+		t.Errorf("htmlToText = %q", got)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rota-of-light/blogAgg/internal/database"
+)
+
+const (
+	minAdaptiveInterval     = 15 * time.Minute
+	maxAdaptiveInterval     = 24 * time.Hour
+	defaultAdaptiveInterval = time.Hour
+)
+
+func clampInterval(interval time.Duration) time.Duration {
+	if interval < minAdaptiveInterval {
+		return minAdaptiveInterval
+	}
+	if interval > maxAdaptiveInterval {
+		return maxAdaptiveInterval
+	}
+	return interval
+}
+
+// adaptiveInterval polls at half the recent posting gap and backs off when there is none.
+func adaptiveInterval(current time.Duration, feed *RSSFeed) time.Duration {
+	if current == 0 {
+		current = defaultAdaptiveInterval
+	}
+	if feed == nil {
+		return clampInterval(current * 3 / 2)
+	}
+	var published []time.Time
+	for _, item := range feed.Channel.Item {
+		if parsed, err := parsePubDate(item.PubDate); err == nil {
+			published = append(published, parsed)
+		}
+	}
+	if len(published) < 2 {
+		return clampInterval(current * 3 / 2)
+	}
+	sort.Slice(published, func(i, j int) bool {
+		return published[i].After(published[j])
+	})
+	if len(published) > 10 {
+		published = published[:10]
+	}
+	span := published[0].Sub(published[len(published)-1])
+	averageGap := span / time.Duration(len(published)-1)
+	return clampInterval(averageGap / 2)
+}
+
+func feedTTL(feed *RSSFeed) time.Duration {
+	minutes, err := strconv.Atoi(strings.TrimSpace(feed.Channel.TTL))
+	if err != nil || minutes <= 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// skipUntilAllowed reads skip hours and days as GMT.
+func skipUntilAllowed(next time.Time, hours, days []string) time.Time {
+	skipHours := map[int]bool{}
+	for _, hour := range hours {
+		if h, err := strconv.Atoi(strings.TrimSpace(hour)); err == nil {
+			skipHours[h%24] = true
+		}
+	}
+	skipDays := map[string]bool{}
+	for _, day := range days {
+		skipDays[strings.ToLower(strings.TrimSpace(day))] = true
+	}
+	if len(skipHours) == 0 && len(skipDays) == 0 {
+		return next
+	}
+	for i := 0; i < 7*24; i++ {
+		utc := next.UTC()
+		if !skipHours[utc.Hour()] && !skipDays[strings.ToLower(utc.Weekday().String())] {
+			return next
+		}
+		next = next.Add(time.Hour).Truncate(time.Hour)
+	}
+	return next
+}
+
+// planNextFetch uses the stored skip times after a 304, when feed is nil.
+func planNextFetch(stored database.Feed, feed *RSSFeed, now time.Time) (sql.NullTime, sql.NullInt32) {
+	var interval time.Duration
+	if stored.FetchIntervalSeconds.Valid {
+		interval = time.Duration(stored.FetchIntervalSeconds.Int32) * time.Second
+	}
+	storedInterval := stored.FetchIntervalSeconds
+	if stored.AdaptiveInterval {
+		interval = adaptiveInterval(interval, feed)
+		storedInterval = sql.NullInt32{
+			Int32: int32(interval / time.Second),
+			Valid: true,
+		}
+	}
+	skipHours, skipDays := stored.SkipHours, stored.SkipDays
+	if feed != nil {
+		if ttl := feedTTL(feed); ttl > interval {
+			interval = ttl
+		}
+		skipHours, skipDays = feed.Channel.SkipHours, feed.Channel.SkipDays
+	}
+	next := skipUntilAllowed(now.Add(interval), skipHours, skipDays)
+	if interval == 0 && next.Equal(now) {
+		return sql.NullTime{}, storedInterval
+	}
+	return sql.NullTime{
+		Time:  next,
+		Valid: true,
+	}, storedInterval
+}
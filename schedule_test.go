@@ -0,0 +1,145 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Rota-of-light/blogAgg/internal/database"
+)
+
+func TestAdaptiveInterval(t *testing.T) {
+	posted := func(dates ...string) *RSSFeed {
+		feed := &RSSFeed{}
+		for _, date := range dates {
+			feed.Channel.Item = append(feed.Channel.Item, RSSItem{PubDate: date})
+		}
+		return feed
+	}
+	tests := []struct {
+		name    string
+		current time.Duration
+		feed    *RSSFeed
+		want    time.Duration
+	}{
+		{"not modified backs off", 2 * time.Hour, nil, 3 * time.Hour},
+		{"starts from the default", 0, nil, 90 * time.Minute},
+		{"backs off to the maximum", 20 * time.Hour, nil, maxAdaptiveInterval},
+		{"too few dates backs off", time.Hour, posted("2006-01-02T15:04:05Z"), 90 * time.Minute},
+		{"half the posting gap", time.Hour, posted("2006-01-02T12:00:00Z", "2006-01-02T08:00:00Z", "2006-01-02T04:00:00Z"), 2 * time.Hour},
+		{"never below the minimum", time.Hour, posted("2006-01-02T12:00:00Z", "2006-01-02T11:59:00Z"), minAdaptiveInterval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := adaptiveInterval(tt.current, tt.feed); got != tt.want {
+				t.Errorf("adaptiveInterval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkipUntilAllowed(t *testing.T) {
+	// 2006-01-02 was a Monday.
+	monday := time.Date(2006, 1, 2, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		next  time.Time
+		hours []string
+		days  []string
+		want  time.Time
+	}{
+		{"nothing to skip", monday, nil, nil, monday},
+		{"allowed hour", monday, []string{"9", "11"}, nil, monday},
+		{"skipped hours", monday, []string{"10", "11"}, nil, time.Date(2006, 1, 2, 12, 0, 0, 0, time.UTC)},
+		{"hour 24 is midnight", time.Date(2006, 1, 2, 0, 15, 0, 0, time.UTC), []string{" 24 "}, nil, time.Date(2006, 1, 2, 1, 0, 0, 0, time.UTC)},
+		{"skipped day", monday, nil, []string{"Monday"}, time.Date(2006, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"days and hours", monday, []string{"0", "1"}, []string{"monday"}, time.Date(2006, 1, 3, 2, 0, 0, 0, time.UTC)},
+		{"days are in GMT", time.Date(2006, 1, 2, 23, 30, 0, 0, time.FixedZone("EST", -5*3600)), nil, []string{"Tuesday"}, time.Date(2006, 1, 4, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := skipUntilAllowed(tt.next, tt.hours, tt.days); !got.Equal(tt.want) {
+				t.Errorf("skipUntilAllowed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanNextFetch(t *testing.T) {
+	now := time.Date(2006, 1, 2, 10, 30, 0, 0, time.UTC)
+	seconds := func(d time.Duration) sql.NullInt32 {
+		return sql.NullInt32{Int32: int32(d / time.Second), Valid: true}
+	}
+	withTTL := func(ttl string, hours ...string) *RSSFeed {
+		feed := &RSSFeed{}
+		feed.Channel.TTL = ttl
+		feed.Channel.SkipHours = hours
+		return feed
+	}
+	tests := []struct {
+		name         string
+		stored       database.Feed
+		feed         *RSSFeed
+		wantNext     time.Time
+		wantInterval sql.NullInt32
+	}{
+		{
+			name:   "no schedule",
+			stored: database.Feed{},
+			feed:   withTTL(""),
+		},
+		{
+			name:         "fixed interval",
+			stored:       database.Feed{FetchIntervalSeconds: seconds(time.Hour)},
+			feed:         withTTL(""),
+			wantNext:     now.Add(time.Hour),
+			wantInterval: seconds(time.Hour),
+		},
+		{
+			name:         "ttl is longer than the interval",
+			stored:       database.Feed{FetchIntervalSeconds: seconds(time.Hour)},
+			feed:         withTTL("120"),
+			wantNext:     now.Add(2 * time.Hour),
+			wantInterval: seconds(time.Hour),
+		},
+		{
+			name:     "ttl alone",
+			stored:   database.Feed{},
+			feed:     withTTL("30"),
+			wantNext: now.Add(30 * time.Minute),
+		},
+		{
+			name:     "skip hours without an interval",
+			stored:   database.Feed{},
+			feed:     withTTL("", "10", "11"),
+			wantNext: time.Date(2006, 1, 2, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "skip hours that do not apply now",
+			stored: database.Feed{},
+			feed:   withTTL("", "3"),
+		},
+		{
+			name:     "not modified uses stored skip hours",
+			stored:   database.Feed{SkipHours: []string{"10"}},
+			wantNext: time.Date(2006, 1, 2, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "not modified backs off adaptive feeds",
+			stored:       database.Feed{FetchIntervalSeconds: seconds(time.Hour), AdaptiveInterval: true, SkipDays: []string{"Tuesday"}},
+			wantNext:     now.Add(90 * time.Minute),
+			wantInterval: seconds(90 * time.Minute),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, interval := planNextFetch(tt.stored, tt.feed, now)
+			if next.Valid != !tt.wantNext.IsZero() || (next.Valid && !next.Time.Equal(tt.wantNext)) {
+				t.Errorf("next = %+v, want %v", next, tt.wantNext)
+			}
+			if interval != tt.wantInterval {
+				t.Errorf("interval = %+v, want %+v", interval, tt.wantInterval)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET last_fetched_at = $1, updated_at = $1, lease_owner = $2, lease_expires_at = $3
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE (next_fetch_at IS NULL OR next_fetch_at <= $1)
+    AND (lease_expires_at IS NULL OR lease_expires_at <= $1)
+    ORDER BY last_fetched_at NULLS FIRST
+    LIMIT $4
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
\ No newline at end of file
@@ -1,12 +1,13 @@
 -- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
-    INSERT INTO feed_follows(id, created_at, updated_at, user_id, feed_id)
+    INSERT INTO feed_follows(id, created_at, updated_at, user_id, feed_id, folder)
     VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6
     )
     RETURNING *
 )
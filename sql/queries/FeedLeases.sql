@@ -0,0 +1,9 @@
+-- name: ReleaseFeedLease :exec
+UPDATE feeds
+SET lease_owner = NULL, lease_expires_at = NULL
+WHERE id = $1 AND lease_owner = $2;
+
+-- name: ReleaseExpiredLeases :execrows
+UPDATE feeds
+SET lease_owner = NULL, lease_expires_at = NULL
+WHERE lease_expires_at <= $1;
\ No newline at end of file
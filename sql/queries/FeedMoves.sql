@@ -0,0 +1,47 @@
+-- name: CreateFeedHistory :exec
+INSERT INTO feed_history (id, created_at, feed_id, event, old_url, new_url)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+);
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
+
+-- name: GetFeedHistory :many
+SELECT * FROM feed_history
+WHERE feed_id = $1
+ORDER BY created_at;
+
+-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = sqlc.arg('target_feed_id')
+WHERE feed_id = sqlc.arg('source_feed_id')
+AND user_id NOT IN (
+    SELECT user_id FROM feed_follows
+    WHERE feed_id = sqlc.arg('target_feed_id')
+);
+
+-- name: MoveFeedHistory :exec
+UPDATE feed_history
+SET feed_id = sqlc.arg('target_feed_id')
+WHERE feed_id = sqlc.arg('source_feed_id');
+
+-- name: MovePosts :exec
+UPDATE posts
+SET feed_id = sqlc.arg('target_feed_id')
+WHERE feed_id = sqlc.arg('source_feed_id')
+AND guid NOT IN (
+    SELECT guid FROM posts
+    WHERE feed_id = sqlc.arg('target_feed_id')
+);
+
+-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $1, updated_at = $2
+WHERE id = $3;
\ No newline at end of file
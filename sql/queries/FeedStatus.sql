@@ -0,0 +1,14 @@
+-- name: DeferFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = $1
+WHERE id = $2;
+
+-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET last_error = $1, last_error_at = $2, consecutive_failures = consecutive_failures + 1, next_fetch_at = $3
+WHERE id = $4;
+
+-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET last_success_at = $1, consecutive_failures = 0, next_fetch_at = $2, fetch_interval_seconds = $3
+WHERE id = $4;
\ No newline at end of file
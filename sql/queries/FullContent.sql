@@ -0,0 +1,27 @@
+-- name: SetFeedFullContent :exec
+UPDATE feeds
+SET fetch_full_content = $1, full_content_since = $2, updated_at = $3
+WHERE id = $4;
+
+-- name: ClaimPostsNeedingContent :many
+UPDATE posts
+SET content_lease_expires_at = sqlc.arg('lease_expires_at'), content_attempts = content_attempts + 1
+WHERE id IN (
+    SELECT posts.id FROM posts
+    INNER JOIN feeds
+    ON feeds.id = posts.feed_id
+    WHERE feeds.fetch_full_content
+    AND posts.created_at >= feeds.full_content_since
+    AND posts.content_fetched_at IS NULL
+    AND posts.content_attempts < sqlc.arg('max_attempts')
+    AND (posts.content_lease_expires_at IS NULL OR posts.content_lease_expires_at <= sqlc.arg('now'))
+    ORDER BY posts.created_at DESC
+    LIMIT sqlc.arg('limit_count')
+    FOR UPDATE OF posts SKIP LOCKED
+)
+RETURNING *;
+
+-- name: SaveExtractedContent :exec
+UPDATE posts
+SET content = $1, content_fetched_at = $2, content_lease_expires_at = NULL
+WHERE id = $3;
\ No newline at end of file
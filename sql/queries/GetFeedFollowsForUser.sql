@@ -1,5 +1,5 @@
 -- name: GetFeedFollowsForUser :many
-SELECT feed_follows.*, feeds.name AS feed_name, users.name AS user_name  
+SELECT feed_follows.*, feeds.name AS feed_name, users.name AS user_name, feeds.url AS feed_url, feeds.site_link AS feed_site_link
 FROM feed_follows
 INNER JOIN feeds
 ON feed_follows.feed_id = feeds.id
@@ -2,6 +2,7 @@
 SELECT posts.* FROM posts
 INNER JOIN feed_follows
 ON feed_follows.feed_id = posts.feed_id
-WHERE feed_follows.user_id = $1
+WHERE feed_follows.user_id = @user_id
+AND (sqlc.narg('category')::TEXT IS NULL OR sqlc.narg('category')::TEXT = ANY(posts.categories))
 ORDER BY posts.published_at DESC
-LIMIT $2;
\ No newline at end of file
+LIMIT @limit_count;
\ No newline at end of file
@@ -1,4 +0,0 @@
--- name: MarkFeedFetched :exec
-UPDATE feeds
-SET last_fetched_at = $1, updated_at = $1
-WHERE id = $2;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: RecordDateParseFailures :exec
+UPDATE feeds
+SET date_parse_failures = date_parse_failures + $1, last_date_parse_error = $2, updated_at = $3
+WHERE id = $4;
\ No newline at end of file
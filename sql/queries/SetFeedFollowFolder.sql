@@ -0,0 +1,5 @@
+-- name: SetFeedFollowFolder :exec
+UPDATE feed_follows
+SET folder = $1, updated_at = $2
+WHERE user_id = $3
+AND feed_id = $4;
\ No newline at end of file
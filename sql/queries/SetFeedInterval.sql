@@ -0,0 +1,4 @@
+-- name: SetFeedInterval :exec
+UPDATE feeds
+SET fetch_interval_seconds = $1, adaptive_interval = $2, next_fetch_at = NULL, updated_at = $3
+WHERE id = $4;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: UpdateFeedCacheHeaders :exec
+UPDATE feeds
+SET etag = $1, last_modified = $2
+WHERE id = $3;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET title = $1, description = $2, site_link = $3, language = $4, image_url = $5, skip_hours = $6, skip_days = $7, updated_at = $8
+WHERE id = $9;
\ No newline at end of file
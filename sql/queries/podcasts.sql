@@ -0,0 +1,38 @@
+-- name: GetMediaPostsByUser :many
+SELECT posts.* FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+AND (posts.enclosure_type LIKE 'audio/%' OR posts.enclosure_type LIKE 'video/%')
+ORDER BY posts.published_at DESC
+LIMIT $2;
+
+-- name: ClaimPendingDownload :one
+UPDATE posts
+SET download_lease_expires_at = sqlc.arg('lease_expires_at')
+WHERE id = (
+    SELECT id FROM posts
+    WHERE downloaded_at IS NULL
+    AND (download_failed_at IS NULL OR download_failed_at < sqlc.arg('retry_before'))
+    AND (download_lease_expires_at IS NULL OR download_lease_expires_at <= sqlc.arg('now'))
+    AND (enclosure_type LIKE 'audio/%' OR enclosure_type LIKE 'video/%')
+    AND (sqlc.narg('user_id')::UUID IS NULL OR EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id
+        AND feed_follows.user_id = sqlc.narg('user_id')::UUID
+    ))
+    ORDER BY download_failed_at IS NOT NULL, published_at DESC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkPostDownloaded :exec
+UPDATE posts
+SET downloaded_at = $1, download_path = $2, download_failed_at = NULL, download_error = NULL, download_lease_expires_at = NULL
+WHERE id = $3;
+
+-- name: MarkDownloadFailed :exec
+UPDATE posts
+SET download_failed_at = $1, download_error = $2, download_lease_expires_at = NULL
+WHERE id = $3;
\ No newline at end of file
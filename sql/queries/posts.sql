@@ -1,5 +1,5 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, content_html, author, categories, comments_url, enclosure_url, enclosure_type, enclosure_length)
 VALUES (
     $1,
     $2,
@@ -8,7 +8,41 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16,
+    $17
 )
-ON CONFLICT (url) DO NOTHING
-RETURNING *;
\ No newline at end of file
+ON CONFLICT (feed_id, guid) DO NOTHING
+RETURNING *;
+
+-- name: GetPostByFeedGUID :one
+SELECT * FROM posts
+WHERE feed_id = $1 AND guid = $2;
+
+-- name: GetPostsByURL :many
+SELECT * FROM posts
+WHERE url = $1;
+
+-- name: UpdatePost :exec
+UPDATE posts
+SET title = $1, url = $2, description = $3, published_at = $4, content_hash = $5, updated_at = $6,
+    content_html = $7, author = $8, categories = $9, comments_url = $10,
+    enclosure_url = $11, enclosure_type = $12, enclosure_length = $13
+WHERE id = $14;
+
+-- name: GetLegacyPost :one
+SELECT * FROM posts
+WHERE feed_id = $1 AND guid = ANY(sqlc.arg('guids')::TEXT[])
+LIMIT 1;
+
+-- name: UpdatePostGUID :exec
+UPDATE posts
+SET guid = $1
+WHERE id = $2;
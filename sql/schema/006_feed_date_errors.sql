@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN date_parse_failures INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN last_date_parse_error TEXT;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN date_parse_failures,
+DROP COLUMN last_date_parse_error;
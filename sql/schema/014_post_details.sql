@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN content_html TEXT,
+ADD COLUMN author TEXT,
+ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN comments_url TEXT,
+ADD COLUMN enclosure_url TEXT,
+ADD COLUMN enclosure_type TEXT,
+ADD COLUMN enclosure_length BIGINT;
+
+-- Hashes now cover the new columns.
+UPDATE posts SET content_hash = NULL;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN content_html,
+DROP COLUMN author,
+DROP COLUMN categories,
+DROP COLUMN comments_url,
+DROP COLUMN enclosure_url,
+DROP COLUMN enclosure_type,
+DROP COLUMN enclosure_length;
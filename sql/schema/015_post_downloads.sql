@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN downloaded_at TIMESTAMP,
+ADD COLUMN download_path TEXT;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN downloaded_at,
+DROP COLUMN download_path;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN title TEXT,
+ADD COLUMN description TEXT,
+ADD COLUMN site_link TEXT,
+ADD COLUMN language TEXT,
+ADD COLUMN image_url TEXT;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN title,
+DROP COLUMN description,
+DROP COLUMN site_link,
+DROP COLUMN language,
+DROP COLUMN image_url;
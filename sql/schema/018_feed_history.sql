@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE feed_history (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    feed_id UUID NOT NULL,
+    FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    old_url TEXT NOT NULL,
+    new_url TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE feed_history;
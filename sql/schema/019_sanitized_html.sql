@@ -0,0 +1,6 @@
+-- +goose Up
+-- Sanitizing changes the stored HTML, and with it the hashes.
+UPDATE posts SET content_hash = NULL;
+
+-- +goose Down
+-- Old hashes cannot be restored; the next fetch rebuilds them.
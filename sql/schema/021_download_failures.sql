@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN download_failed_at TIMESTAMP,
+ADD COLUMN download_error TEXT;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN download_failed_at,
+DROP COLUMN download_error;
@@ -0,0 +1,5 @@
+-- +goose Up
+-- Normalized links change the hash of every post with a link.
+UPDATE posts SET content_hash = NULL;
+
+-- +goose Down
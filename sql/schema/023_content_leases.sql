@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN content_lease_expires_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN content_lease_expires_at;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN skip_hours TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN skip_days TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN skip_hours,
+DROP COLUMN skip_days;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN download_lease_expires_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN download_lease_expires_at;
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN full_content_since TIMESTAMP;
+UPDATE feeds SET full_content_since = NOW() WHERE fetch_full_content;
+
+ALTER TABLE posts
+ADD COLUMN content_attempts INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN content_attempts;
+
+ALTER TABLE feeds
+DROP COLUMN full_content_since;
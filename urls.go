@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"igshid":  true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	return strings.HasPrefix(key, "utm_") || trackingParams[key]
+}
+
+// normalizeURL lowercases the scheme and host and drops default ports and tracking parameters.
+func normalizeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return raw
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+	if (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	parsed.Host = host
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	}
+	if parsed.RawQuery != "" {
+		var kept []string
+		for _, part := range strings.Split(parsed.RawQuery, "&") {
+			key, _, _ := strings.Cut(part, "=")
+			if unescaped, err := url.QueryUnescape(key); err == nil {
+				key = unescaped
+			}
+			if part != "" && !isTrackingParam(key) {
+				kept = append(kept, part)
+			}
+		}
+		parsed.RawQuery = strings.Join(kept, "&")
+	}
+	return parsed.String()
+}
+
+func resolveAgainst(base, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if base == "" || ref == "" {
+		return ref
+	}
+	baseURL, err := url.Parse(strings.TrimSpace(base))
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return normalizeURL(base.ResolveReference(refURL).String())
+}
+
+// resolveFeedLinks resolves RSS and JSON Feed items against the site link, Atom entries
+// against the document.
+func resolveFeedLinks(feed *RSSFeed, feedURL string) {
+	documentBase, err := url.Parse(feedURL)
+	if err != nil {
+		return
+	}
+	feed.Channel.Link = resolveURL(documentBase, feed.Channel.Link)
+	feed.Channel.ImageURL = resolveURL(documentBase, feed.Channel.ImageURL)
+	itemBase := documentBase
+	if feed.Format != "Atom" {
+		if siteURL, err := url.Parse(feed.Channel.Link); err == nil && siteURL.IsAbs() {
+			itemBase = siteURL
+		}
+	}
+	for i := range feed.Channel.Item {
+		item := &feed.Channel.Item[i]
+		if item.RawLink == "" {
+			item.RawLink = strings.TrimSpace(item.Link)
+		}
+		item.Link = resolveURL(itemBase, item.Link)
+		item.Comments = resolveURL(itemBase, item.Comments)
+		if item.Enclosure != nil {
+			item.Enclosure.URL = resolveURL(itemBase, item.Enclosure.URL)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://example.com/post", "https://example.com/post"},
+		{"HTTPS://Example.COM/Post", "https://example.com/Post"},
+		{"http://example.com:80/a", "http://example.com/a"},
+		{"https://example.com:443/a", "https://example.com/a"},
+		{"https://example.com:8443/a", "https://example.com:8443/a"},
+		{"https://example.com", "https://example.com/"},
+		{"https://example.com/a?utm_source=rss&id=7&utm_medium=feed", "https://example.com/a?id=7"},
+		{"https://example.com/a?fbclid=x&b=2&a=1", "https://example.com/a?b=2&a=1"},
+		{"https://example.com/a?UTM_Campaign=x", "https://example.com/a"},
+		{"https://example.com/a#section", "https://example.com/a#section"},
+		{"http://[::1]:80/a", "http://[::1]/a"},
+		{"  https://example.com/a  ", "https://example.com/a"},
+		{"/relative/path", "/relative/path"},
+		{"mailto:someone@example.com", "mailto:someone@example.com"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := normalizeURL(tt.in); got != tt.want {
+				t.Errorf("normalizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}